@@ -11,7 +11,10 @@ enum SwitchLabels {
 }
 
 interface ReadingData {
-    distance: number;
+    // distance is missing when nothing is in range or the measurement failed
+    distance?: number;
+    // unit of distance: m (default), cm, mm or in
+    unit?: string;
 }
 
 class RobotContext {
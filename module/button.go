@@ -0,0 +1,205 @@
+package learningrobotics
+
+import (
+	"time"
+)
+
+// buttonEdge is a change in a button's debounced state.
+type buttonEdge int
+
+const (
+	edgeNone buttonEdge = iota
+	edgePressed
+	edgeReleased
+)
+
+func (e buttonEdge) String() string {
+	switch e {
+	case edgePressed:
+		return "pressed"
+	case edgeReleased:
+		return "released"
+	default:
+		return "none"
+	}
+}
+
+// debouncedButton turns raw samples of a button into debounced press and
+// release edges. A new level is only accepted once it has been stable for the
+// debounce window, so contact bounce and short noise spikes are ignored.
+type debouncedButton struct {
+	debounce time.Duration
+
+	pressed bool
+	// candidate is a raw level that differs from pressed and is waiting to be
+	// confirmed, observed since candidateSince
+	candidate      bool
+	candidateSince time.Time
+	pending        bool
+
+	presses     int
+	releases    int
+	bounces     int
+	lastPress   time.Time
+	lastRelease time.Time
+}
+
+// sample feeds a raw level observed at t and returns the resulting edge, if any.
+func (b *debouncedButton) sample(pressed bool, t time.Time) buttonEdge {
+	if pressed == b.pressed {
+		if b.pending {
+			// the level went back before it was confirmed
+			b.bounces++
+			b.pending = false
+		}
+		return edgeNone
+	}
+	if !b.pending {
+		b.pending = true
+		b.candidate = pressed
+		b.candidateSince = t
+	}
+	if t.Sub(b.candidateSince) < b.debounce {
+		return edgeNone
+	}
+
+	b.pending = false
+	b.pressed = pressed
+	if pressed {
+		b.presses++
+		b.lastPress = t
+		return edgePressed
+	}
+	b.releases++
+	b.lastRelease = t
+	return edgeReleased
+}
+
+// deadline returns when a pending level will be confirmed if it stays stable.
+// Interrupt driven buttons only see samples on edges, so they sample again at
+// this time to confirm the level.
+func (b *debouncedButton) deadline() (time.Time, bool) {
+	if !b.pending {
+		return time.Time{}, false
+	}
+	return b.candidateSince.Add(b.debounce), true
+}
+
+func (b *debouncedButton) state() map[string]any {
+	state := map[string]any{
+		"pressed":  b.pressed,
+		"presses":  b.presses,
+		"releases": b.releases,
+		"bounces":  b.bounces,
+	}
+	if !b.lastPress.IsZero() {
+		state["last_press"] = b.lastPress.Format(time.RFC3339Nano)
+	}
+	if !b.lastRelease.IsZero() {
+		state["last_release"] = b.lastRelease.Format(time.RFC3339Nano)
+	}
+	return state
+}
+
+// buttonGesture is an action recognised from a button's debounced edges.
+type buttonGesture int
+
+const (
+	gestureNone buttonGesture = iota
+	// gesturePress fires as soon as the button is pressed
+	gesturePress
+	// gestureClick fires for a short press, once it can no longer become a
+	// double click or long press
+	gestureClick
+	gestureDoubleClick
+	// gestureLongPress fires while the button is still held
+	gestureLongPress
+)
+
+func (g buttonGesture) String() string {
+	switch g {
+	case gesturePress:
+		return "press"
+	case gestureClick:
+		return "click"
+	case gestureDoubleClick:
+		return "double_click"
+	case gestureLongPress:
+		return "long_press"
+	default:
+		return "none"
+	}
+}
+
+// gestureDetector recognises clicks, double clicks and long presses from
+// debounced edges. A zero longPress or doubleClick disables that gesture,
+// which lets clicks fire as soon as the button is released.
+type gestureDetector struct {
+	longPress   time.Duration
+	doubleClick time.Duration
+
+	held        bool
+	pressedAt   time.Time
+	longFired   bool
+	secondPress bool
+	// clickPending is set between the release of a click and the end of the
+	// double click window
+	clickPending bool
+	releasedAt   time.Time
+}
+
+// edge feeds a debounced edge observed at t and returns the gesture it completes.
+func (g *gestureDetector) edge(edge buttonEdge, t time.Time) buttonGesture {
+	switch edge {
+	case edgePressed:
+		g.held = true
+		g.pressedAt = t
+		g.longFired = false
+		g.secondPress = g.clickPending && t.Sub(g.releasedAt) <= g.doubleClick
+		g.clickPending = false
+		return gesturePress
+	case edgeReleased:
+		g.held = false
+		if g.longFired {
+			return gestureNone
+		}
+		if g.secondPress {
+			g.secondPress = false
+			return gestureDoubleClick
+		}
+		if g.doubleClick > 0 {
+			g.clickPending = true
+			g.releasedAt = t
+			return gestureNone
+		}
+		return gestureClick
+	default:
+		return gestureNone
+	}
+}
+
+// tick returns a gesture that completes by time t without a new edge: a long
+// press that is still held, or a click whose double click window has passed.
+func (g *gestureDetector) tick(t time.Time) buttonGesture {
+	if g.held && g.longPress > 0 && !g.longFired && t.Sub(g.pressedAt) >= g.longPress {
+		g.longFired = true
+		g.secondPress = false
+		return gestureLongPress
+	}
+	if g.clickPending && t.Sub(g.releasedAt) > g.doubleClick {
+		g.clickPending = false
+		return gestureClick
+	}
+	return gestureNone
+}
+
+// deadline returns when tick next needs to be called.
+func (g *gestureDetector) deadline() (time.Time, bool) {
+	if g.held && g.longPress > 0 && !g.longFired {
+		return g.pressedAt.Add(g.longPress), true
+	}
+	if g.clickPending {
+		return g.releasedAt.Add(g.doubleClick + time.Millisecond), true
+	}
+	return time.Time{}, false
+}
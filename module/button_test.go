@@ -0,0 +1,218 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// at returns a time ms milliseconds after a fixed start.
+func at(ms int) time.Time {
+	return time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC).Add(time.Duration(ms) * time.Millisecond)
+}
+
+type buttonSample struct {
+	pressed bool
+	ms      int
+}
+
+func TestDebouncedButton(t *testing.T) {
+	tests := []struct {
+		name        string
+		samples     []buttonSample
+		wantEdges   []buttonEdge
+		wantPressed bool
+		wantBounces int
+	}{
+		{
+			name:        "stable press",
+			samples:     []buttonSample{{true, 0}, {true, 10}, {true, 20}},
+			wantEdges:   []buttonEdge{edgeNone, edgeNone, edgePressed},
+			wantPressed: true,
+		},
+		{
+			name: "press and release",
+			samples: []buttonSample{
+				{true, 0}, {true, 20}, {false, 100}, {false, 120},
+			},
+			wantEdges: []buttonEdge{edgeNone, edgePressed, edgeNone, edgeReleased},
+		},
+		{
+			name:        "contact bounce",
+			samples:     []buttonSample{{true, 0}, {false, 5}, {true, 10}, {true, 25}, {true, 30}},
+			wantEdges:   []buttonEdge{edgeNone, edgeNone, edgeNone, edgeNone, edgePressed},
+			wantPressed: true,
+			wantBounces: 1,
+		},
+		{
+			name:        "noise spike",
+			samples:     []buttonSample{{true, 0}, {false, 10}, {false, 40}},
+			wantEdges:   []buttonEdge{edgeNone, edgeNone, edgeNone},
+			wantBounces: 1,
+		},
+		{
+			name:        "no repeated edge while held",
+			samples:     []buttonSample{{true, 0}, {true, 20}, {true, 40}, {true, 60}},
+			wantEdges:   []buttonEdge{edgeNone, edgePressed, edgeNone, edgeNone},
+			wantPressed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &debouncedButton{debounce: 20 * time.Millisecond}
+			var edges []buttonEdge
+			for _, s := range tt.samples {
+				edges = append(edges, b.sample(s.pressed, at(s.ms)))
+			}
+			if !slices.Equal(edges, tt.wantEdges) {
+				t.Errorf("edges = %v, want %v", edges, tt.wantEdges)
+			}
+			if b.pressed != tt.wantPressed {
+				t.Errorf("pressed = %v, want %v", b.pressed, tt.wantPressed)
+			}
+			if b.bounces != tt.wantBounces {
+				t.Errorf("bounces = %d, want %d", b.bounces, tt.wantBounces)
+			}
+		})
+	}
+}
+
+func TestDebouncedButtonDeadline(t *testing.T) {
+	b := &debouncedButton{debounce: 20 * time.Millisecond}
+	if _, ok := b.deadline(); ok {
+		t.Fatal("deadline set with no pending level")
+	}
+
+	b.sample(true, at(5))
+	deadline, ok := b.deadline()
+	if !ok || !deadline.Equal(at(25)) {
+		t.Fatalf("deadline() = %v, %v, want %v", deadline, ok, at(25))
+	}
+	if edge := b.sample(true, deadline); edge != edgePressed {
+		t.Errorf("sample at the deadline = %v, want pressed", edge)
+	}
+	if _, ok := b.deadline(); ok {
+		t.Error("deadline still set after the level was confirmed")
+	}
+}
+
+// gestureStep feeds an edge to a gestureDetector, or calls tick for edgeNone.
+type gestureStep struct {
+	edge buttonEdge
+	ms   int
+}
+
+func TestGestureDetector(t *testing.T) {
+	tests := []struct {
+		name        string
+		longPress   time.Duration
+		doubleClick time.Duration
+		steps       []gestureStep
+		want        []string
+	}{
+		{
+			name:  "click fires on release without double click",
+			steps: []gestureStep{{edgePressed, 0}, {edgeReleased, 100}},
+			want:  []string{"press@0", "click@100"},
+		},
+		{
+			name:        "click waits for the double click window",
+			doubleClick: 400 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeReleased, 100}, {edgeNone, 500}, {edgeNone, 501},
+			},
+			want: []string{"press@0", "click@501"},
+		},
+		{
+			name:        "double click",
+			doubleClick: 400 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeReleased, 100}, {edgePressed, 300}, {edgeReleased, 400}, {edgeNone, 1000},
+			},
+			want: []string{"press@0", "press@300", "double_click@400"},
+		},
+		{
+			name:        "second press after the window is a new click",
+			doubleClick: 400 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeReleased, 100}, {edgeNone, 501},
+				{edgePressed, 600}, {edgeReleased, 700}, {edgeNone, 1101},
+			},
+			want: []string{"press@0", "click@501", "press@600", "click@1101"},
+		},
+		{
+			name:      "long press fires while held",
+			longPress: 800 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeNone, 799}, {edgeNone, 800}, {edgeNone, 900}, {edgeReleased, 1000},
+			},
+			want: []string{"press@0", "long_press@800"},
+		},
+		{
+			name:        "short press is not a long press",
+			longPress:   800 * time.Millisecond,
+			doubleClick: 400 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeReleased, 300}, {edgeNone, 700}, {edgeNone, 800},
+			},
+			want: []string{"press@0", "click@800"},
+		},
+		{
+			name:        "long press on the second press is not a double click",
+			longPress:   800 * time.Millisecond,
+			doubleClick: 400 * time.Millisecond,
+			steps: []gestureStep{
+				{edgePressed, 0}, {edgeReleased, 100}, {edgePressed, 300}, {edgeNone, 1100}, {edgeReleased, 1200},
+			},
+			want: []string{"press@0", "press@300", "long_press@1100"},
+		},
+		{
+			name:  "disabled long press",
+			steps: []gestureStep{{edgePressed, 0}, {edgeNone, 5000}},
+			want:  []string{"press@0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &gestureDetector{longPress: tt.longPress, doubleClick: tt.doubleClick}
+			var got []string
+			for _, step := range tt.steps {
+				var gesture buttonGesture
+				if step.edge == edgeNone {
+					gesture = g.tick(at(step.ms))
+				} else {
+					gesture = g.edge(step.edge, at(step.ms))
+				}
+				if gesture != gestureNone {
+					got = append(got, fmt.Sprintf("%v@%d", gesture, step.ms))
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("gestures = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGestureDetectorDeadline(t *testing.T) {
+	g := &gestureDetector{longPress: 800 * time.Millisecond, doubleClick: 400 * time.Millisecond}
+	if _, ok := g.deadline(); ok {
+		t.Fatal("deadline set while idle")
+	}
+
+	g.edge(edgePressed, at(0))
+	if deadline, ok := g.deadline(); !ok || !deadline.Equal(at(800)) {
+		t.Errorf("deadline while held = %v, %v, want %v", deadline, ok, at(800))
+	}
+	g.edge(edgeReleased, at(100))
+	if deadline, ok := g.deadline(); !ok || !deadline.Equal(at(501)) {
+		t.Errorf("deadline after a click = %v, %v, want %v", deadline, ok, at(501))
+	}
+	if gesture := g.tick(at(501)); gesture != gestureClick {
+		t.Errorf("tick at the deadline = %v, want click", gesture)
+	}
+	if _, ok := g.deadline(); ok {
+		t.Error("deadline still set after the click fired")
+	}
+}
@@ -16,6 +16,7 @@ func main() {
 		resource.APIModel{generic.API, learningrobotics.RgbLed},
 		resource.APIModel{generic.API, learningrobotics.LightSwitch},
 		resource.APIModel{sensor.API, learningrobotics.UltrasonicSensor},
+		resource.APIModel{sensor.API, learningrobotics.UltrasonicArray},
 		resource.APIModel{sensor.API, learningrobotics.JoystickAdc},
 		resource.APIModel{sw.API, learningrobotics.RgbPq},
 		resource.APIModel{generic.API, learningrobotics.PriorityQueueSwitch},
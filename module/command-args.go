@@ -0,0 +1,200 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// commandArgs decodes the arguments of a DoCommand. JSON clients send numbers,
+// numeric strings or booleans depending on the language they are written in,
+// so each accessor accepts every form it can convert unambiguously. Errors
+// always name the offending field, prefixed with the path of nested arguments.
+type commandArgs struct {
+	path   string
+	values map[string]any
+}
+
+func newCommandArgs(path string, values map[string]any) commandArgs {
+	return commandArgs{path: path, values: values}
+}
+
+// field returns the full name of key for error messages.
+func (a commandArgs) field(key string) string {
+	if a.path == "" {
+		return key
+	}
+	return a.path + "." + key
+}
+
+func (a commandArgs) has(key string) bool {
+	_, ok := a.values[key]
+	return ok
+}
+
+func (a commandArgs) required(key string) (any, error) {
+	raw, ok := a.values[key]
+	if !ok {
+		return nil, fmt.Errorf("%s is required", a.field(key))
+	}
+	return raw, nil
+}
+
+func (a commandArgs) intArg(key string, def int) (int, error) {
+	if !a.has(key) {
+		return def, nil
+	}
+	return a.requiredIntArg(key)
+}
+
+func (a commandArgs) requiredIntArg(key string) (int, error) {
+	raw, err := a.required(key)
+	if err != nil {
+		return 0, err
+	}
+	return decodeInt(a.field(key), raw)
+}
+
+func (a commandArgs) floatArg(key string, def float64) (float64, error) {
+	if !a.has(key) {
+		return def, nil
+	}
+	return a.requiredFloatArg(key)
+}
+
+func (a commandArgs) requiredFloatArg(key string) (float64, error) {
+	raw, err := a.required(key)
+	if err != nil {
+		return 0, err
+	}
+	return decodeFloat(a.field(key), raw)
+}
+
+func (a commandArgs) boolArg(key string, def bool) (bool, error) {
+	if !a.has(key) {
+		return def, nil
+	}
+	return decodeBool(a.field(key), a.values[key])
+}
+
+func (a commandArgs) stringArg(key, def string) (string, error) {
+	if !a.has(key) {
+		return def, nil
+	}
+	return a.requiredStringArg(key)
+}
+
+func (a commandArgs) requiredStringArg(key string) (string, error) {
+	raw, err := a.required(key)
+	if err != nil {
+		return "", err
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string, got %v", a.field(key), raw)
+	}
+	return value, nil
+}
+
+// mapArg returns the nested arguments under key. A missing key, or a value
+// such as true that only marks the command, yields empty arguments.
+func (a commandArgs) mapArg(key string) (commandArgs, error) {
+	nested := newCommandArgs(a.field(key), map[string]any{})
+	switch raw := a.values[key].(type) {
+	case map[string]any:
+		nested.values = raw
+	case nil, bool:
+	default:
+		return nested, fmt.Errorf("%s must be a map, got %v", a.field(key), raw)
+	}
+	return nested, nil
+}
+
+// checkMin reports an error naming key when value is below min.
+func (a commandArgs) checkMin(key string, value, min int) error {
+	if value < min {
+		return fmt.Errorf("%s must be at least %d, got %d", a.field(key), min, value)
+	}
+	return nil
+}
+
+// checkRange reports an error naming key when value is outside [min, max].
+func (a commandArgs) checkRange(key string, value, min, max float64) error {
+	if !(value >= min && value <= max) {
+		return fmt.Errorf("%s must be between %v and %v, got %v", a.field(key), min, max, value)
+	}
+	return nil
+}
+
+// decodeInt accepts whole JSON numbers and integer strings such as "5" that
+// fit in an int.
+func decodeInt(field string, raw any) (int, error) {
+	switch v := raw.(type) {
+	case float64:
+		// -MinInt is a power of two, so it converts to float64 exactly
+		if v == math.Trunc(v) && v >= math.MinInt && v < -float64(math.MinInt) {
+			return int(v), nil
+		}
+	case int:
+		return v, nil
+	case int64:
+		if int64(int(v)) == v {
+			return int(v), nil
+		}
+	case string:
+		if value, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be an integer, got %v", field, raw)
+}
+
+// decodeFloat accepts finite JSON numbers and numeric strings such as "0.5".
+// NaN and infinities are rejected because they slip through range checks.
+func decodeFloat(field string, raw any) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		if isFinite(v) {
+			return v, nil
+		}
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		if value, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && isFinite(value) {
+			return value, nil
+		}
+	}
+	return 0, fmt.Errorf("%s must be a number, got %v", field, raw)
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// decodeBool accepts booleans, strings such as "true" and the numbers 0 and 1.
+func decodeBool(field string, raw any) (bool, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case int:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case int64:
+		if v == 0 || v == 1 {
+			return v == 1, nil
+		}
+	case string:
+		if value, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return value, nil
+		}
+	}
+	return false, fmt.Errorf("%s must be a boolean, got %v", field, raw)
+}
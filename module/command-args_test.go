@@ -0,0 +1,148 @@
+package learningrobotics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodeInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    int
+		wantErr bool
+	}{
+		{name: "whole float", raw: 5.0, want: 5},
+		{name: "negative float", raw: -3.0, want: -3},
+		{name: "int", raw: 7, want: 7},
+		{name: "int64", raw: int64(9), want: 9},
+		{name: "string", raw: "42", want: 42},
+		{name: "padded string", raw: " 42 ", want: 42},
+		{name: "min int", raw: float64(math.MinInt), want: math.MinInt},
+		{name: "fraction", raw: 1.5, wantErr: true},
+		{name: "too large", raw: 1e30, wantErr: true},
+		{name: "too small", raw: -1e30, wantErr: true},
+		{name: "max int rounds up", raw: float64(math.MaxInt), wantErr: true},
+		{name: "infinity", raw: math.Inf(1), wantErr: true},
+		{name: "nan", raw: math.NaN(), wantErr: true},
+		{name: "string too large", raw: "99999999999999999999", wantErr: true},
+		{name: "fraction string", raw: "1.5", wantErr: true},
+		{name: "bool", raw: true, wantErr: true},
+		{name: "nil", raw: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeInt("count", tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeInt(%v) = %d, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeInt(%v) failed: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeInt(%v) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeFloat(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    float64
+		wantErr bool
+	}{
+		{name: "float", raw: 0.5, want: 0.5},
+		{name: "int", raw: 2, want: 2},
+		{name: "int64", raw: int64(-4), want: -4},
+		{name: "string", raw: "0.25", want: 0.25},
+		{name: "padded string", raw: " 1e3 ", want: 1000},
+		{name: "word", raw: "half", wantErr: true},
+		{name: "bool", raw: false, wantErr: true},
+		{name: "nil", raw: nil, wantErr: true},
+		{name: "NaN string", raw: "NaN", wantErr: true},
+		{name: "Inf string", raw: "Inf", wantErr: true},
+		{name: "negative Inf string", raw: "-inf", wantErr: true},
+		{name: "overflowing string", raw: "1e400", wantErr: true},
+		{name: "NaN", raw: math.NaN(), wantErr: true},
+		{name: "Inf", raw: math.Inf(1), wantErr: true},
+		{name: "negative Inf", raw: math.Inf(-1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeFloat("level", tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeFloat(%v) = %v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeFloat(%v) failed: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeFloat(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBool(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		want    bool
+		wantErr bool
+	}{
+		{name: "true", raw: true, want: true},
+		{name: "false", raw: false, want: false},
+		{name: "float one", raw: 1.0, want: true},
+		{name: "float zero", raw: 0.0, want: false},
+		{name: "int one", raw: 1, want: true},
+		{name: "int zero", raw: 0, want: false},
+		{name: "int64 one", raw: int64(1), want: true},
+		{name: "string", raw: "true", want: true},
+		{name: "padded string", raw: " false ", want: false},
+		{name: "float two", raw: 2.0, wantErr: true},
+		{name: "int two", raw: 2, wantErr: true},
+		{name: "int64 minus one", raw: int64(-1), wantErr: true},
+		{name: "word", raw: "yes", wantErr: true},
+		{name: "nil", raw: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeBool("enabled", tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeBool(%v) = %v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeBool(%v) failed: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeBool(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandArgsErrorsNameField(t *testing.T) {
+	args := newCommandArgs("party_mode", map[string]any{"occurences": "many"})
+	_, err := args.requiredIntArg("occurences")
+	if err == nil {
+		t.Fatal("requiredIntArg accepted a word")
+	}
+	if want := "party_mode.occurences must be an integer, got many"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+
+	if _, err := args.requiredIntArg("missing"); err == nil || err.Error() != "party_mode.missing is required" {
+		t.Errorf("missing argument error = %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// distance units accepted by the ultrasonic sensors
+const (
+	unitMeters      = "m"
+	unitCentimeters = "cm"
+	unitMillimeters = "mm"
+	unitInches      = "in"
+)
+
+// perMeter is how many of each unit make a meter.
+var perMeter = map[string]float64{
+	unitMeters:      1,
+	unitCentimeters: 100,
+	unitMillimeters: 1000,
+	unitInches:      1 / 0.0254,
+}
+
+// HC-SR04 measuring limits in meters
+const (
+	defaultMinRangeMeters = 0.02
+	defaultMaxRangeMeters = 4.0
+)
+
+// validateUnit checks a units attribute. An empty unit means meters.
+func validateUnit(unit string) error {
+	if unit == "" {
+		return nil
+	}
+	if _, ok := perMeter[unit]; !ok {
+		units := slices.Sorted(maps.Keys(perMeter))
+		return fmt.Errorf("units must be one of %s, got %q", strings.Join(units, ", "), unit)
+	}
+	return nil
+}
+
+// distanceRange converts distances from meters into a unit and checks them
+// against the range the sensor can measure.
+type distanceRange struct {
+	unit     string
+	min, max float64
+}
+
+// newDistanceRange returns the range for unit, with min and max in that unit
+// defaulting to the HC-SR04 limits.
+func newDistanceRange(unit string, min, max *float64) distanceRange {
+	if unit == "" {
+		unit = unitMeters
+	}
+	r := distanceRange{
+		unit: unit,
+		min:  defaultMinRangeMeters * perMeter[unit],
+		max:  defaultMaxRangeMeters * perMeter[unit],
+	}
+	if min != nil {
+		r.min = *min
+	}
+	if max != nil {
+		r.max = *max
+	}
+	return r
+}
+
+func (r distanceRange) fromMeters(meters float64) float64 {
+	return meters * perMeter[r.unit]
+}
+
+func (r distanceRange) contains(distance float64) bool {
+	return distance >= r.min && distance <= r.max
+}
+
+// distanceToMeters converts a distance reported in unit back into meters.
+func distanceToMeters(distance float64, unit string) float64 {
+	if factor, ok := perMeter[unit]; ok {
+		return distance / factor
+	}
+	return distance
+}
@@ -32,6 +32,11 @@ type EventSystemConfig struct {
 	RGBSwitchName        string `json:"rgb_switch_name"`
 	BuzzerPin            string `json:"buzzer_pin"`
 	BoardName            string `json:"board_name"`
+
+	// OnClose is "off" (default), "hold" or "set", which drives the buzzer at
+	// the PWM duty cycle in OnCloseValue when the service closes.
+	OnClose      string   `json:"on_close,omitempty"`
+	OnCloseValue *float64 `json:"on_close_value,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -52,6 +57,12 @@ func (cfg *EventSystemConfig) Validate(path string) ([]string, []string, error)
 	if cfg.BoardName == "" {
 		return nil, nil, errors.New("board_name is required")
 	}
+	if err := validateOnClose(cfg.OnClose, cfg.OnCloseValue != nil); err != nil {
+		return nil, nil, err
+	}
+	if cfg.OnCloseValue != nil && (*cfg.OnCloseValue < 0 || *cfg.OnCloseValue > 1) {
+		return nil, nil, fmt.Errorf("on_close_value must be a duty cycle between 0 and 1, got %v", *cfg.OnCloseValue)
+	}
 	return nil, nil, nil
 }
 
@@ -65,6 +76,7 @@ type eventSystemEventSystem struct {
 
 	cancelCtx  context.Context
 	cancelFunc func()
+	workers    sync.WaitGroup
 
 	buzzerPin        board.GPIOPin
 	rgbSwitch        sw.Switch
@@ -119,35 +131,45 @@ func NewEventSystem(ctx context.Context, deps resource.Dependencies, name resour
 		mq:               mq,
 	}
 
+	// subscribers skip messages once cancelCtx is done, and Close waits for
+	// any that are still running before applying the on_close policy
 	mq.Subscribe(func(message EventMessage) {
+		if cancelCtx.Err() != nil {
+			return
+		}
 		distance := message.data.(float64)
 		if distance < 0.3 {
 			// Red
-			s.rgbSwitch.SetPosition(context.Background(), 1, map[string]interface{}{})
+			s.rgbSwitch.SetPosition(cancelCtx, 1, map[string]interface{}{})
 		} else {
 			// Green
-			s.rgbSwitch.SetPosition(context.Background(), 2, map[string]interface{}{})
+			s.rgbSwitch.SetPosition(cancelCtx, 2, map[string]interface{}{})
 		}
 	})
 
 	mq.Subscribe(func(message EventMessage) {
+		if cancelCtx.Err() != nil {
+			return
+		}
 		distance := message.data.(float64)
 
 		if distance < 0.1 {
-			s.buzzerPin.SetPWM(context.Background(), 0.05, map[string]interface{}{})
-			s.buzzerPin.SetPWMFreq(context.Background(), 1000, map[string]interface{}{})
+			s.buzzerPin.SetPWM(cancelCtx, 0.05, map[string]interface{}{})
+			s.buzzerPin.SetPWMFreq(cancelCtx, 1000, map[string]interface{}{})
 		} else if distance <= 0.4 {
-			s.buzzerPin.SetPWM(context.Background(), 0.1, map[string]interface{}{})
-			s.buzzerPin.SetPWMFreq(context.Background(), 800, map[string]interface{}{})
+			s.buzzerPin.SetPWM(cancelCtx, 0.1, map[string]interface{}{})
+			s.buzzerPin.SetPWMFreq(cancelCtx, 800, map[string]interface{}{})
 		} else if distance <= 0.7 {
-			s.buzzerPin.SetPWM(context.Background(), 0.2, map[string]interface{}{})
-			s.buzzerPin.SetPWMFreq(context.Background(), 500, map[string]interface{}{})
+			s.buzzerPin.SetPWM(cancelCtx, 0.2, map[string]interface{}{})
+			s.buzzerPin.SetPWMFreq(cancelCtx, 500, map[string]interface{}{})
 		} else {
-			s.buzzerPin.SetPWM(context.Background(), 0, map[string]interface{}{})
+			s.buzzerPin.SetPWM(cancelCtx, 0, map[string]interface{}{})
 		}
 	})
 
-	go s.pollUltrasonicSensor(cancelCtx)
+	s.workers.Go(func() {
+		s.pollUltrasonicSensor(cancelCtx)
+	})
 
 	return s, nil
 }
@@ -160,22 +182,44 @@ func (s *eventSystemEventSystem) DoCommand(ctx context.Context, cmd map[string]i
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (s *eventSystemEventSystem) Close(context.Context) error {
-	// Put close code here
+func (s *eventSystemEventSystem) Close(ctx context.Context) error {
 	s.cancelFunc()
-	return nil
+	s.workers.Wait()
+	// the poller has stopped, so nothing publishes while the queue drains
+	s.mq.Close()
+	// the RGB switch is a dependency and applies its own on_close policy
+	return applyOnClose(ctx, s.logger, s.cfg.OnClose, func(ctx context.Context, policy string) error {
+		duty := 0.0
+		if policy == onCloseSet {
+			duty = *s.cfg.OnCloseValue
+		}
+		return s.buzzerPin.SetPWM(ctx, duty, map[string]interface{}{})
+	})
 }
 
 func (s *eventSystemEventSystem) pollUltrasonicSensor(cancelCtx context.Context) {
 	ticker := time.NewTicker(time.Millisecond * 100) // ( recommended of 60 ms between readings)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-cancelCtx.Done():
+			return
+		case <-ticker.C:
+		}
 		readings, err := s.ultrasonicSensor.Readings(cancelCtx, map[string]interface{}{})
 		if err != nil {
 			continue
 		}
-		distance := readings["distance"].(float64)
+		distance, ok := readings["distance"].(float64)
+		if !ok {
+			// nothing is in range of the sensor
+			continue
+		}
+		// the thresholds below are in meters
+		if unit, ok := readings["unit"].(string); ok {
+			distance = distanceToMeters(distance, unit)
+		}
 		s.mq.Publish(EventMessage{topic: "distance", data: distance})
 	}
 }
@@ -189,6 +233,8 @@ type MessageQueue struct {
 	ch          chan EventMessage
 	subscribers []func(EventMessage)
 	mu          sync.Mutex
+	handlers    sync.WaitGroup
+	done        chan struct{}
 }
 
 func NewMessageQueue(bufferSize int) *MessageQueue {
@@ -196,6 +242,7 @@ func NewMessageQueue(bufferSize int) *MessageQueue {
 		ch:          make(chan EventMessage, bufferSize),
 		subscribers: make([]func(EventMessage), 0),
 		mu:          sync.Mutex{},
+		done:        make(chan struct{}),
 	}
 
 	go mq.start()
@@ -218,8 +265,19 @@ func (mq *MessageQueue) start() {
 	for message := range mq.ch {
 		mq.mu.Lock()
 		for _, subscriber := range mq.subscribers {
-			go subscriber(message)
+			mq.handlers.Go(func() {
+				subscriber(message)
+			})
 		}
 		mq.mu.Unlock()
 	}
+	close(mq.done)
+}
+
+// Close stops delivering messages and waits for running subscribers to
+// return. Publish must not be called after Close.
+func (mq *MessageQueue) Close() {
+	close(mq.ch)
+	<-mq.done
+	mq.handlers.Wait()
 }
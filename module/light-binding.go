@@ -0,0 +1,262 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+)
+
+// defaultBindingName names the binding made from a light-switch's top-level
+// attributes when it has no bindings list.
+const defaultBindingName = "light"
+
+// LightBinding connects buttons to one light: the buttons used by Mode and the
+// output they drive, which is an output pin, a switch component or a generic
+// service.
+type LightBinding struct {
+	Name string `json:"name"`
+
+	LightOutputPin string `json:"light_output_pin,omitempty"`
+	// SwitchName or ServiceName drive a switch component or a generic service
+	// instead of LightOutputPin. The service is sent OnCommand and OffCommand.
+	SwitchName  string                 `json:"switch_name,omitempty"`
+	ServiceName string                 `json:"service_name,omitempty"`
+	OnCommand   map[string]interface{} `json:"on_command,omitempty"`
+	OffCommand  map[string]interface{} `json:"off_command,omitempty"`
+
+	// Mode is two_button (default), toggle, long_press or double_click. The
+	// single button modes read ButtonInputPin instead of the on and off buttons.
+	Mode              string `json:"mode,omitempty"`
+	OnButtonInputPin  string `json:"on_button_input_pin,omitempty"`
+	OffButtonInputPin string `json:"off_button_input_pin,omitempty"`
+	ButtonInputPin    string `json:"button_input_pin,omitempty"`
+
+	// AutoOffAfter is a duration such as "10m" after which the light turns
+	// off unless it is changed again. Schedule limits when it can be turned on.
+	AutoOffAfter string                `json:"auto_off_after,omitempty"`
+	Schedule     []LightScheduleWindow `json:"schedule,omitempty"`
+}
+
+// validate checks the binding. path prefixes the attribute names in errors,
+// such as "bindings.0", and is empty for the top-level binding.
+func (b *LightBinding) validate(path string) error {
+	field := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	outputs := 0
+	for _, name := range []string{b.LightOutputPin, b.SwitchName, b.ServiceName} {
+		if name != "" {
+			outputs++
+		}
+	}
+	if outputs != 1 {
+		return fmt.Errorf("exactly one of %s, %s or %s is required",
+			field("light_output_pin"), field("switch_name"), field("service_name"))
+	}
+	if b.ServiceName != "" && (b.OnCommand == nil || b.OffCommand == nil) {
+		return fmt.Errorf("%s and %s are required with service_name", field("on_command"), field("off_command"))
+	}
+	switch b.Mode {
+	case "", lightSwitchTwoButton:
+		if b.OnButtonInputPin == "" {
+			return fmt.Errorf("%s is required", field("on_button_input_pin"))
+		}
+		if b.OffButtonInputPin == "" {
+			return fmt.Errorf("%s is required", field("off_button_input_pin"))
+		}
+	case lightSwitchToggle, lightSwitchLongPress, lightSwitchDoubleClick:
+		if b.ButtonInputPin == "" {
+			return fmt.Errorf("%s is required in %s mode", field("button_input_pin"), b.Mode)
+		}
+	default:
+		return fmt.Errorf("%s must be one of %s, %s, %s or %s, got %q", field("mode"),
+			lightSwitchTwoButton, lightSwitchToggle, lightSwitchLongPress, lightSwitchDoubleClick, b.Mode)
+	}
+	if _, err := b.autoOffAfter(); err != nil {
+		return fmt.Errorf("%s: %w", field("auto_off_after"), err)
+	}
+	if _, err := parseSchedule(b.Schedule); err != nil {
+		if path != "" {
+			return fmt.Errorf("%s.%w", path, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// autoOffAfter parses AutoOffAfter, returning 0 when it is not set.
+func (b *LightBinding) autoOffAfter() (time.Duration, error) {
+	if b.AutoOffAfter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(b.AutoOffAfter)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("must be a positive duration such as \"10m\", got %q", b.AutoOffAfter)
+	}
+	return d, nil
+}
+
+// hasAttributes reports whether any binding attribute is set, which is not
+// allowed at the top level of a light-switch that has a bindings list.
+func (b *LightBinding) hasAttributes() bool {
+	return b.LightOutputPin != "" || b.SwitchName != "" || b.ServiceName != "" ||
+		b.OnCommand != nil || b.OffCommand != nil || b.Mode != "" ||
+		b.OnButtonInputPin != "" || b.OffButtonInputPin != "" || b.ButtonInputPin != "" ||
+		b.AutoOffAfter != "" || len(b.Schedule) > 0
+}
+
+// lightBinding is a LightBinding in use by a running light-switch.
+type lightBinding struct {
+	name         string
+	cfg          LightBinding
+	light        lightOutput
+	buttons      []*switchButton
+	autoOffAfter time.Duration
+	schedule     []scheduleWindow
+
+	// changeMu serialises light changes so a toggle always sees the latest state
+	changeMu sync.Mutex
+
+	// guarded by the light-switch's mu
+	lightOn bool
+	// changedBy and changed record the source and time of the last change
+	changedBy string
+	changed   time.Time
+	// autoOffAt is when the light turns off by itself, zero when it will not
+	autoOffAt time.Time
+}
+
+// state reports the binding for get_state. The caller holds the light-switch's mu.
+func (b *lightBinding) state() map[string]any {
+	mode := b.cfg.Mode
+	if mode == "" {
+		mode = lightSwitchTwoButton
+	}
+	output := "pin"
+	switch {
+	case b.cfg.SwitchName != "":
+		output = "switch"
+	case b.cfg.ServiceName != "":
+		output = "service"
+	}
+	buttons := map[string]any{}
+	for _, button := range b.buttons {
+		buttons[button.label] = button.state()
+	}
+	state := map[string]any{
+		"light_on": b.lightOn,
+		"output":   output,
+		"mode":     mode,
+		"buttons":  buttons,
+	}
+	if !b.changed.IsZero() {
+		state["changed_by"] = b.changedBy
+		state["changed_at"] = b.changed.Format(time.RFC3339Nano)
+	}
+	if !b.autoOffAt.IsZero() {
+		state["auto_off_at"] = b.autoOffAt.Format(time.RFC3339Nano)
+		state["auto_off_remaining_ms"] = max(time.Until(b.autoOffAt), 0).Milliseconds()
+	}
+	if len(b.schedule) > 0 {
+		state["in_schedule"] = scheduleAllows(b.schedule, time.Now())
+	}
+	return state
+}
+
+// switchButton is a button wired between an input pin and ground, so the pin
+// reads low while the button is pressed.
+type switchButton struct {
+	debouncedButton
+	gestures gestureDetector
+	// actions maps the gestures this button responds to onto light actions
+	actions map[buttonGesture]string
+	binding *lightBinding
+
+	label     string
+	pinName   string
+	pin       board.GPIOPin
+	interrupt board.DigitalInterrupt
+}
+
+// newSwitchButtons creates the buttons used by the binding's mode.
+func newSwitchButtons(b board.Board, conf *LightSwitchConfig, binding *lightBinding) ([]*switchButton, error) {
+	debounce := time.Duration(defaultDebounceMs) * time.Millisecond
+	if conf.DebounceMs != nil {
+		debounce = time.Duration(*conf.DebounceMs) * time.Millisecond
+	}
+	longPress := time.Duration(defaultLongPressMs) * time.Millisecond
+	if conf.LongPressMs != nil {
+		longPress = time.Duration(*conf.LongPressMs) * time.Millisecond
+	}
+	doubleClick := time.Duration(defaultDoubleClickMs) * time.Millisecond
+	if conf.DoubleClickMs != nil {
+		doubleClick = time.Duration(*conf.DoubleClickMs) * time.Millisecond
+	}
+
+	var buttons []*switchButton
+	newButton := func(label, pinName string, actions map[buttonGesture]string) *switchButton {
+		button := &switchButton{
+			debouncedButton: debouncedButton{debounce: debounce},
+			actions:         actions,
+			binding:         binding,
+			label:           label,
+			pinName:         pinName,
+		}
+		buttons = append(buttons, button)
+		return button
+	}
+	cfg := binding.cfg
+	switch cfg.Mode {
+	case lightSwitchToggle:
+		newButton("button", cfg.ButtonInputPin, map[buttonGesture]string{gesturePress: lightActionToggle})
+	case lightSwitchLongPress:
+		button := newButton("button", cfg.ButtonInputPin, map[buttonGesture]string{
+			gestureClick:     lightActionOn,
+			gestureLongPress: lightActionOff,
+		})
+		button.gestures.longPress = longPress
+	case lightSwitchDoubleClick:
+		button := newButton("button", cfg.ButtonInputPin, map[buttonGesture]string{
+			gestureClick:       lightActionOn,
+			gestureDoubleClick: lightActionOff,
+		})
+		button.gestures.doubleClick = doubleClick
+	default:
+		newButton("on", cfg.OnButtonInputPin, map[buttonGesture]string{gesturePress: lightActionOn})
+		newButton("off", cfg.OffButtonInputPin, map[buttonGesture]string{gesturePress: lightActionOff})
+	}
+
+	for _, button := range buttons {
+		pin, err := b.GPIOPinByName(button.pinName)
+		if err != nil {
+			return nil, err
+		}
+		button.pin = pin
+	}
+	return buttons, nil
+}
+
+// validateBindings checks a bindings list, including that names are unique.
+func validateBindings(bindings []LightBinding) error {
+	names := map[string]bool{}
+	for i, binding := range bindings {
+		path := fmt.Sprintf("bindings.%d", i)
+		if binding.Name == "" {
+			return fmt.Errorf("%s.name is required", path)
+		}
+		if names[binding.Name] {
+			return fmt.Errorf("%s.name %q is used by more than one binding", path, binding.Name)
+		}
+		names[binding.Name] = true
+		if err := binding.validate(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package learningrobotics
+
+import (
+	"context"
+	"fmt"
+
+	"go.viam.com/rdk/components/board"
+	sw "go.viam.com/rdk/components/switch"
+	"go.viam.com/rdk/resource"
+	generic "go.viam.com/rdk/services/generic"
+)
+
+// lightOutput is the light driven by a light-switch.
+type lightOutput interface {
+	// apply performs a light action given whether the light is on and returns
+	// whether it is on afterwards.
+	apply(ctx context.Context, action string, on bool) (bool, error)
+	// set turns the light on or off.
+	set(ctx context.Context, on bool) error
+}
+
+// newLightOutput returns the output configured for a binding: an output pin,
+// a switch component or a generic service.
+func newLightOutput(deps resource.Dependencies, b board.Board, conf *LightBinding) (lightOutput, error) {
+	switch {
+	case conf.SwitchName != "":
+		s, err := sw.FromProvider(deps, conf.SwitchName)
+		if err != nil {
+			return nil, err
+		}
+		return switchLightOutput{sw: s}, nil
+	case conf.ServiceName != "":
+		svc, err := generic.FromProvider(deps, conf.ServiceName)
+		if err != nil {
+			return nil, err
+		}
+		return commandLightOutput{svc: svc, onCommand: conf.OnCommand, offCommand: conf.OffCommand}, nil
+	default:
+		pin, err := b.GPIOPinByName(conf.LightOutputPin)
+		if err != nil {
+			return nil, err
+		}
+		return pinLightOutput{pin: pin}, nil
+	}
+}
+
+// actionTarget returns whether the light should be on after action.
+func actionTarget(action string, on bool) bool {
+	switch action {
+	case lightActionOn:
+		return true
+	case lightActionOff:
+		return false
+	default:
+		return !on
+	}
+}
+
+// pinLightOutput drives the light high or low from a GPIO pin.
+type pinLightOutput struct {
+	pin board.GPIOPin
+}
+
+func (o pinLightOutput) apply(ctx context.Context, action string, on bool) (bool, error) {
+	on = actionTarget(action, on)
+	return on, o.set(ctx, on)
+}
+
+func (o pinLightOutput) set(ctx context.Context, on bool) error {
+	return o.pin.Set(ctx, on, map[string]interface{}{})
+}
+
+// switchLightOutput cycles through the positions of a switch component, where
+// position 0 is off. Turning the light on advances to the next on position, so
+// repeated presses cycle through them, and toggling steps through every
+// position including off.
+type switchLightOutput struct {
+	sw sw.Switch
+}
+
+func (o switchLightOutput) apply(ctx context.Context, action string, on bool) (bool, error) {
+	position, err := o.sw.GetPosition(ctx, map[string]interface{}{})
+	if err != nil {
+		return on, err
+	}
+	count, _, err := o.sw.GetNumberOfPositions(ctx, map[string]interface{}{})
+	if err != nil {
+		return on, err
+	}
+	if count < 2 {
+		return on, fmt.Errorf("switch %s needs at least 2 positions, has %d", o.sw.Name().ShortName(), count)
+	}
+
+	var next uint32
+	switch action {
+	case lightActionOn:
+		next = position%(count-1) + 1
+	case lightActionOff:
+		next = 0
+	default:
+		next = (position + 1) % count
+	}
+	if err := o.sw.SetPosition(ctx, next, map[string]interface{}{}); err != nil {
+		return position != 0, err
+	}
+	return next != 0, nil
+}
+
+func (o switchLightOutput) set(ctx context.Context, on bool) error {
+	var position uint32
+	if on {
+		position = 1
+	}
+	return o.sw.SetPosition(ctx, position, map[string]interface{}{})
+}
+
+// commandLightOutput sends the configured DoCommands to a generic service.
+type commandLightOutput struct {
+	svc        resource.Resource
+	onCommand  map[string]interface{}
+	offCommand map[string]interface{}
+}
+
+func (o commandLightOutput) apply(ctx context.Context, action string, on bool) (bool, error) {
+	target := actionTarget(action, on)
+	if err := o.set(ctx, target); err != nil {
+		return on, err
+	}
+	return target, nil
+}
+
+func (o commandLightOutput) set(ctx context.Context, on bool) error {
+	cmd := o.offCommand
+	if on {
+		cmd = o.onCommand
+	}
+	_, err := o.svc.DoCommand(ctx, cmd)
+	return err
+}
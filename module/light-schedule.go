@@ -0,0 +1,99 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// LightScheduleWindow is a daily window, in the machine's local time, during
+// which a light may be turned on. Start and End are "HH:MM"; a window whose
+// end is before its start runs past midnight. Days limits the window to the
+// days it starts on, such as "mon", and defaults to every day.
+type LightScheduleWindow struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+}
+
+var scheduleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// scheduleWindow is a parsed LightScheduleWindow with times in minutes since
+// midnight.
+type scheduleWindow struct {
+	start, end int
+	// days is indexed by time.Weekday, nil for every day
+	days []bool
+}
+
+// parseSchedule parses the windows of a schedule attribute.
+func parseSchedule(windows []LightScheduleWindow) ([]scheduleWindow, error) {
+	parsed := make([]scheduleWindow, 0, len(windows))
+	for i, w := range windows {
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.%d.start: %w", i, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return nil, fmt.Errorf("schedule.%d.end: %w", i, err)
+		}
+		window := scheduleWindow{start: start, end: end}
+		if len(w.Days) > 0 {
+			window.days = make([]bool, len(scheduleDays))
+			for _, day := range w.Days {
+				index := slices.Index(scheduleDays, strings.ToLower(day))
+				if index < 0 {
+					return nil, fmt.Errorf("schedule.%d.days: unknown day %q, expected one of %s", i, day, strings.Join(scheduleDays, ", "))
+				}
+				window.days[index] = true
+			}
+		}
+		parsed = append(parsed, window)
+	}
+	return parsed, nil
+}
+
+// parseClock parses an "HH:MM" time of day into minutes since midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("expected a time such as \"07:30\", got %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w scheduleWindow) onDay(day time.Weekday) bool {
+	return w.days == nil || w.days[day]
+}
+
+// contains reports whether t falls in the window. A window with equal start
+// and end covers the whole day.
+func (w scheduleWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case w.start == w.end:
+		return w.onDay(t.Weekday())
+	case w.start < w.end:
+		return w.onDay(t.Weekday()) && minute >= w.start && minute < w.end
+	case minute >= w.start:
+		return w.onDay(t.Weekday())
+	case minute < w.end:
+		// the part after midnight belongs to the window that started yesterday
+		return w.onDay((t.Weekday() + 6) % 7)
+	default:
+		return false
+	}
+}
+
+// scheduleAllows reports whether t falls in any window. An empty schedule
+// allows any time.
+func scheduleAllows(windows []scheduleWindow, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(windows, func(w scheduleWindow) bool {
+		return w.contains(t)
+	})
+}
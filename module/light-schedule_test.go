@@ -0,0 +1,185 @@
+package learningrobotics
+
+import (
+	"testing"
+	"time"
+)
+
+// on returns the given time of day on a date in the week of Monday 6 January
+// 2025, where day 0 is that Monday.
+func on(day, hour, minute int) time.Time {
+	return time.Date(2025, 1, 6+day, hour, minute, 0, 0, time.Local)
+}
+
+const (
+	monday = iota
+	tuesday
+	wednesday
+	thursday
+	friday
+	saturday
+	sunday
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "00:00", want: 0},
+		{value: "07:30", want: 450},
+		{value: "23:59", want: 1439},
+		{value: "24:00", wantErr: true},
+		{value: "7pm", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseClock(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseClock(%q) = %d, want error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseClock(%q) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseClock(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []LightScheduleWindow
+		wantErr string
+	}{
+		{
+			name:    "bad start",
+			windows: []LightScheduleWindow{{Start: "7", End: "08:00"}},
+			wantErr: `schedule.0.start: expected a time such as "07:30", got "7"`,
+		},
+		{
+			name:    "bad end",
+			windows: []LightScheduleWindow{{Start: "07:00", End: "08:00"}, {Start: "07:00", End: "late"}},
+			wantErr: `schedule.1.end: expected a time such as "07:30", got "late"`,
+		},
+		{
+			name:    "unknown day",
+			windows: []LightScheduleWindow{{Start: "07:00", End: "08:00", Days: []string{"mon", "someday"}}},
+			wantErr: `schedule.0.days: unknown day "someday", expected one of sun, mon, tue, wed, thu, fri, sat`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSchedule(tt.windows)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("parseSchedule() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleWindowContains(t *testing.T) {
+	tests := []struct {
+		name   string
+		window LightScheduleWindow
+		at     time.Time
+		want   bool
+	}{
+		{name: "inside day window", window: LightScheduleWindow{Start: "07:00", End: "09:00"}, at: on(monday, 8, 0), want: true},
+		{name: "day window start is inclusive", window: LightScheduleWindow{Start: "07:00", End: "09:00"}, at: on(monday, 7, 0), want: true},
+		{name: "day window end is exclusive", window: LightScheduleWindow{Start: "07:00", End: "09:00"}, at: on(monday, 9, 0), want: false},
+		{name: "before day window", window: LightScheduleWindow{Start: "07:00", End: "09:00"}, at: on(monday, 6, 59), want: false},
+		{name: "overnight before midnight", window: LightScheduleWindow{Start: "22:00", End: "06:00"}, at: on(monday, 23, 30), want: true},
+		{name: "overnight after midnight", window: LightScheduleWindow{Start: "22:00", End: "06:00"}, at: on(tuesday, 5, 59), want: true},
+		{name: "overnight end is exclusive", window: LightScheduleWindow{Start: "22:00", End: "06:00"}, at: on(tuesday, 6, 0), want: false},
+		{name: "overnight gap", window: LightScheduleWindow{Start: "22:00", End: "06:00"}, at: on(tuesday, 12, 0), want: false},
+		{name: "equal start and end is all day", window: LightScheduleWindow{Start: "00:00", End: "00:00"}, at: on(friday, 15, 0), want: true},
+		{
+			name:   "day limited window on its day",
+			window: LightScheduleWindow{Start: "07:00", End: "09:00", Days: []string{"mon", "wed"}},
+			at:     on(wednesday, 8, 0),
+			want:   true,
+		},
+		{
+			name:   "day limited window on another day",
+			window: LightScheduleWindow{Start: "07:00", End: "09:00", Days: []string{"mon", "wed"}},
+			at:     on(tuesday, 8, 0),
+			want:   false,
+		},
+		{
+			name:   "days are case insensitive",
+			window: LightScheduleWindow{Start: "07:00", End: "09:00", Days: []string{"Thu"}},
+			at:     on(thursday, 8, 0),
+			want:   true,
+		},
+		{
+			name:   "overnight window continues into the next day",
+			window: LightScheduleWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}},
+			at:     on(saturday, 3, 0),
+			want:   true,
+		},
+		{
+			name:   "overnight window does not start on the next day",
+			window: LightScheduleWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}},
+			at:     on(saturday, 23, 0),
+			want:   false,
+		},
+		{
+			name:   "morning of the start day belongs to the day before",
+			window: LightScheduleWindow{Start: "22:00", End: "06:00", Days: []string{"fri"}},
+			at:     on(friday, 3, 0),
+			want:   false,
+		},
+		{
+			name:   "overnight window from sunday wraps to monday",
+			window: LightScheduleWindow{Start: "22:00", End: "06:00", Days: []string{"sun"}},
+			at:     on(sunday+1, 3, 0),
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := parseSchedule([]LightScheduleWindow{tt.window})
+			if err != nil {
+				t.Fatalf("parseSchedule() failed: %v", err)
+			}
+			if got := windows[0].contains(tt.at); got != tt.want {
+				t.Errorf("contains(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleAllows(t *testing.T) {
+	if !scheduleAllows(nil, on(monday, 3, 0)) {
+		t.Error("an empty schedule should allow any time")
+	}
+
+	windows, err := parseSchedule([]LightScheduleWindow{
+		{Start: "06:00", End: "08:00"},
+		{Start: "18:00", End: "23:00"},
+	})
+	if err != nil {
+		t.Fatalf("parseSchedule() failed: %v", err)
+	}
+	for _, tt := range []struct {
+		at   time.Time
+		want bool
+	}{
+		{on(monday, 7, 0), true},
+		{on(monday, 12, 0), false},
+		{on(monday, 19, 0), true},
+		{on(monday, 23, 30), false},
+	} {
+		if got := scheduleAllows(windows, tt.at); got != tt.want {
+			t.Errorf("scheduleAllows(%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+}
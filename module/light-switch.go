@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.viam.com/rdk/components/board"
@@ -24,11 +25,94 @@ func init() {
 	)
 }
 
+// light-switch modes
+const (
+	// lightSwitchTwoButton uses separate on and off buttons. It is the default.
+	lightSwitchTwoButton = "two_button"
+	// lightSwitchToggle toggles the light on every press of a single button.
+	lightSwitchToggle = "toggle"
+	// lightSwitchLongPress turns the light on with a click of a single button
+	// and off by holding it.
+	lightSwitchLongPress = "long_press"
+	// lightSwitchDoubleClick turns the light on with a click of a single
+	// button and off with a double click.
+	lightSwitchDoubleClick = "double_click"
+)
+
+// light actions triggered by button gestures and remote commands
+const (
+	lightActionOn     = "on"
+	lightActionOff    = "off"
+	lightActionToggle = "toggle"
+)
+
+// remoteActions maps the light-switch DoCommands onto light actions.
+var remoteActions = map[string]string{
+	"turn_on":  lightActionOn,
+	"turn_off": lightActionOff,
+	"toggle":   lightActionToggle,
+}
+
+// sources of light changes reported by get_state
+const (
+	lightSourceButton  = "button"
+	lightSourceRemote  = "remote"
+	lightSourceAutoOff = "auto_off"
+)
+
+// autoOffCheckInterval is how often the auto-off timer is checked.
+const autoOffCheckInterval = 100 * time.Millisecond
+
+var errOutsideSchedule = errors.New("the light can only be turned on within its schedule")
+
+const (
+	defaultDebounceMs    = 20
+	defaultLongPressMs   = 800
+	defaultDoubleClickMs = 400
+)
+
 type LightSwitchConfig struct {
-	LightOutputPin    string `json:"light_output_pin"`
-	OnButtonInputPin  string `json:"on_button_input_pin"`
-	OffButtonInputPin string `json:"off_button_input_pin"`
+	LightOutputPin    string `json:"light_output_pin,omitempty"`
+	OnButtonInputPin  string `json:"on_button_input_pin,omitempty"`
+	OffButtonInputPin string `json:"off_button_input_pin,omitempty"`
 	BoardName         string `json:"board_name"`
+
+	// SwitchName or ServiceName drive a switch component or a generic service
+	// instead of LightOutputPin. The service is sent OnCommand and OffCommand.
+	SwitchName  string                 `json:"switch_name,omitempty"`
+	ServiceName string                 `json:"service_name,omitempty"`
+	OnCommand   map[string]interface{} `json:"on_command,omitempty"`
+	OffCommand  map[string]interface{} `json:"off_command,omitempty"`
+
+	// Mode is two_button (default), toggle, long_press or double_click. The
+	// single button modes read ButtonInputPin instead of the on and off buttons.
+	Mode           string `json:"mode,omitempty"`
+	ButtonInputPin string `json:"button_input_pin,omitempty"`
+	LongPressMs    *int   `json:"long_press_ms,omitempty"`
+	DoubleClickMs  *int   `json:"double_click_ms,omitempty"`
+
+	// UseInterrupts watches the buttons with digital interrupts instead of
+	// polling them, falling back to polling when the board has no interrupts
+	// for the button pins.
+	UseInterrupts bool `json:"use_interrupts,omitempty"`
+
+	// DebounceMs is how long a button must hold a new level before it counts
+	// as a press or release.
+	DebounceMs *int `json:"debounce_ms,omitempty"`
+
+	// AutoOffAfter is a duration such as "10m" after which the light turns
+	// off unless it is changed again. Schedule limits when it can be turned on.
+	AutoOffAfter string                `json:"auto_off_after,omitempty"`
+	Schedule     []LightScheduleWindow `json:"schedule,omitempty"`
+
+	// Bindings serves several lights from one light-switch, replacing the
+	// top-level light, button and timer attributes above.
+	Bindings []LightBinding `json:"bindings,omitempty"`
+
+	// OnClose is "off" (default), "hold" or "set", which sets the light to
+	// OnCloseValue when the service closes.
+	OnClose      string `json:"on_close,omitempty"`
+	OnCloseValue *bool  `json:"on_close_value,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -36,19 +120,72 @@ type LightSwitchConfig struct {
 // The path is the JSON path in your robot's config (not the `Config` struct) to the
 // resource being validated; e.g. "components.0".
 func (cfg *LightSwitchConfig) Validate(path string) ([]string, []string, error) {
-	if cfg.LightOutputPin == "" {
-		return nil, nil, errors.New("light_output_pin is required")
+	if len(cfg.Bindings) > 0 {
+		top := cfg.topLevelBinding()
+		if top.hasAttributes() {
+			return nil, nil, errors.New("light, button and timer attributes must be set in each binding when bindings is used")
+		}
+		if err := validateBindings(cfg.Bindings); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		top := cfg.topLevelBinding()
+		if err := top.validate(""); err != nil {
+			return nil, nil, err
+		}
 	}
-	if cfg.OnButtonInputPin == "" {
-		return nil, nil, errors.New("on_button_input_pin is required")
+	if cfg.LongPressMs != nil && *cfg.LongPressMs <= 0 {
+		return nil, nil, fmt.Errorf("long_press_ms must be positive, got %d", *cfg.LongPressMs)
 	}
-	if cfg.OffButtonInputPin == "" {
-		return nil, nil, errors.New("off_button_input_pin is required")
+	if cfg.DoubleClickMs != nil && *cfg.DoubleClickMs <= 0 {
+		return nil, nil, fmt.Errorf("double_click_ms must be positive, got %d", *cfg.DoubleClickMs)
 	}
 	if cfg.BoardName == "" {
 		return nil, nil, errors.New("board_name is required")
 	}
-	return nil, nil, nil
+	if cfg.DebounceMs != nil && *cfg.DebounceMs < 0 {
+		return nil, nil, fmt.Errorf("debounce_ms must not be negative, got %d", *cfg.DebounceMs)
+	}
+	if err := validateOnClose(cfg.OnClose, cfg.OnCloseValue != nil); err != nil {
+		return nil, nil, err
+	}
+	var deps []string
+	for _, binding := range cfg.bindings() {
+		if binding.SwitchName != "" {
+			deps = append(deps, binding.SwitchName)
+		}
+		if binding.ServiceName != "" {
+			deps = append(deps, binding.ServiceName)
+		}
+	}
+	return deps, nil, nil
+}
+
+// topLevelBinding returns the binding described by the top-level attributes.
+func (cfg *LightSwitchConfig) topLevelBinding() LightBinding {
+	return LightBinding{
+		Name:              defaultBindingName,
+		LightOutputPin:    cfg.LightOutputPin,
+		SwitchName:        cfg.SwitchName,
+		ServiceName:       cfg.ServiceName,
+		OnCommand:         cfg.OnCommand,
+		OffCommand:        cfg.OffCommand,
+		Mode:              cfg.Mode,
+		OnButtonInputPin:  cfg.OnButtonInputPin,
+		OffButtonInputPin: cfg.OffButtonInputPin,
+		ButtonInputPin:    cfg.ButtonInputPin,
+		AutoOffAfter:      cfg.AutoOffAfter,
+		Schedule:          cfg.Schedule,
+	}
+}
+
+// bindings returns the configured bindings, or the top-level binding when
+// there is no bindings list.
+func (cfg *LightSwitchConfig) bindings() []LightBinding {
+	if len(cfg.Bindings) > 0 {
+		return cfg.Bindings
+	}
+	return []LightBinding{cfg.topLevelBinding()}
 }
 
 type learningRoboticsLightSwitch struct {
@@ -61,10 +198,16 @@ type learningRoboticsLightSwitch struct {
 
 	cancelCtx  context.Context
 	cancelFunc func()
+	workers    sync.WaitGroup
 
-	lightOutputPin    board.GPIOPin
-	onButtonInputPin  board.GPIOPin
-	offButtonInputPin board.GPIOPin
+	bindings []*lightBinding
+	// buttons holds the buttons of every binding
+	buttons []*switchButton
+	// set when the buttons are watched with interrupts
+	ticksChan chan board.Tick
+	health    *loopHealth
+
+	mu sync.Mutex
 }
 
 func newLearningRoboticsLightSwitch(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (resource.Resource, error) {
@@ -85,32 +228,62 @@ func NewLightSwitch(ctx context.Context, deps resource.Dependencies, name resour
 		cancelFunc()
 		return nil, err
 	}
-	lightOutputPin, err := board.GPIOPinByName(conf.LightOutputPin)
-	if err != nil {
-		cancelFunc()
-		return nil, err
+	s := &learningRoboticsLightSwitch{
+		name:       name,
+		logger:     logger,
+		cfg:        conf,
+		cancelCtx:  cancelCtx,
+		cancelFunc: cancelFunc,
+		health:     newLoopHealth(),
 	}
-	onButtonInputPin, err := board.GPIOPinByName(conf.OnButtonInputPin)
-	if err != nil {
-		cancelFunc()
-		return nil, err
+	autoOff := false
+	for _, cfg := range conf.bindings() {
+		binding := &lightBinding{name: cfg.Name, cfg: cfg}
+		if binding.light, err = newLightOutput(deps, board, &cfg); err != nil {
+			cancelFunc()
+			return nil, err
+		}
+		if binding.buttons, err = newSwitchButtons(board, conf, binding); err != nil {
+			cancelFunc()
+			return nil, err
+		}
+		if binding.autoOffAfter, err = cfg.autoOffAfter(); err != nil {
+			cancelFunc()
+			return nil, err
+		}
+		if binding.schedule, err = parseSchedule(cfg.Schedule); err != nil {
+			cancelFunc()
+			return nil, err
+		}
+		autoOff = autoOff || binding.autoOffAfter > 0
+		s.bindings = append(s.bindings, binding)
+		s.buttons = append(s.buttons, binding.buttons...)
 	}
-	offButtonInputPin, err := board.GPIOPinByName(conf.OffButtonInputPin)
-	if err != nil {
-		cancelFunc()
-		return nil, err
+	if conf.UseInterrupts {
+		if err := s.streamButtonTicks(cancelCtx, board); err != nil {
+			logger.Warnf("digital interrupts unavailable for the buttons, falling back to polling: %v", err)
+		}
+	}
+	s.workers.Go(func() {
+		defer s.health.stop()
+		if s.ticksChan != nil {
+			err := s.runInterrupts(cancelCtx)
+			if cancelCtx.Err() != nil {
+				return
+			}
+			s.health.fail(err)
+			logger.Errorf("button interrupts failed, falling back to polling: %v", err)
+			s.mu.Lock()
+			s.ticksChan = nil
+			s.mu.Unlock()
+		}
+		s.run(cancelCtx)
+	})
+	if autoOff {
+		s.workers.Go(func() {
+			s.runAutoOff(cancelCtx)
+		})
 	}
-	s := &learningRoboticsLightSwitch{
-		name:              name,
-		logger:            logger,
-		cfg:               conf,
-		cancelCtx:         cancelCtx,
-		cancelFunc:        cancelFunc,
-		lightOutputPin:    lightOutputPin,
-		onButtonInputPin:  onButtonInputPin,
-		offButtonInputPin: offButtonInputPin,
-	}
-	go s.run(cancelCtx)
 	return s, nil
 }
 
@@ -119,39 +292,334 @@ func (s *learningRoboticsLightSwitch) Name() resource.Name {
 }
 
 func (s *learningRoboticsLightSwitch) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
-	return nil, fmt.Errorf("not implemented")
+	if _, ok := cmd["get_state"]; ok {
+		return s.getState(), nil
+	}
+	if _, ok := cmd["get_health"]; ok {
+		return s.health.state(), nil
+	}
+	args := newCommandArgs("", cmd)
+	for _, action := range []string{"turn_on", "turn_off", "toggle"} {
+		if !args.has(action) {
+			continue
+		}
+		targets, err := s.targetBindings(args, action)
+		if err != nil {
+			return nil, err
+		}
+		lights := map[string]interface{}{}
+		for _, binding := range targets {
+			on, err := s.applyAction(ctx, binding, remoteActions[action], lightSourceRemote)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", binding.name, err)
+			}
+			lights[binding.name] = on
+		}
+		resp := map[string]interface{}{"status": "success", "lights": lights}
+		if len(targets) == 1 {
+			resp["light_on"] = lights[targets[0].name]
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("Unknown command: %v", cmd)
 }
 
-func (s *learningRoboticsLightSwitch) Close(context.Context) error {
-	// Put close code here
+func (s *learningRoboticsLightSwitch) Close(ctx context.Context) error {
 	s.cancelFunc()
-	return nil
+	// wait for the loop so it cannot change the light after on_close is applied
+	s.workers.Wait()
+	return applyOnClose(ctx, s.logger, s.cfg.OnClose, func(ctx context.Context, policy string) error {
+		on := policy == onCloseSet && *s.cfg.OnCloseValue
+		var errs []error
+		for _, binding := range s.bindings {
+			if err := binding.light.set(ctx, on); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", binding.name, err))
+			}
+		}
+		return errors.Join(errs...)
+	})
 }
 
+// targetBindings returns the bindings a remote command applies to. The
+// command's value names one binding, or is true or null for all of them.
+func (s *learningRoboticsLightSwitch) targetBindings(args commandArgs, command string) ([]*lightBinding, error) {
+	switch raw := args.values[command].(type) {
+	case nil:
+		return s.bindings, nil
+	case bool:
+		if raw {
+			return s.bindings, nil
+		}
+	case string:
+		for _, binding := range s.bindings {
+			if binding.name == raw {
+				return []*lightBinding{binding}, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: unknown binding %q", args.field(command), raw)
+	}
+	return nil, fmt.Errorf("%s must be true or a binding name, got %v", args.field(command), args.values[command])
+}
+
+// run polls the buttons until ctx is cancelled. Board errors are logged and
+// retried with backoff so a transient failure does not stop the switch.
 func (s *learningRoboticsLightSwitch) run(ctx context.Context) error {
 	ticker := time.NewTicker(time.Second / 30) // 30 frames per second
 	defer ticker.Stop()
 
+	var backoff retryBackoff
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			lightOnValue, err := s.onButtonInputPin.Get(ctx, map[string]interface{}{})
-			if err != nil {
-				return err
+			if err := s.pollButtons(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				wait := backoff.wait()
+				s.health.fail(err)
+				s.logger.Warnf("reading buttons failed, retrying in %v: %v", wait, err)
+				if err := sleepContext(ctx, wait); err != nil {
+					return err
+				}
+				continue
 			}
-			if !lightOnValue {
-				s.lightOutputPin.Set(ctx, true, map[string]interface{}{})
+			if backoff.failing() {
+				// only clear degraded on recovery, so light output failures stay visible
+				backoff.reset()
+				s.health.ok()
 			}
+		}
+	}
+}
 
-			lightOffValue, err := s.offButtonInputPin.Get(ctx, map[string]interface{}{})
-			if err != nil {
-				return err
+func (s *learningRoboticsLightSwitch) pollButtons(ctx context.Context) error {
+	for _, button := range s.buttons {
+		high, err := button.pin.Get(ctx, map[string]interface{}{})
+		if err != nil {
+			return fmt.Errorf("reading %s button: %w", button.label, err)
+		}
+		s.sampleButton(ctx, button, !high, time.Now())
+	}
+	s.checkButtons(ctx, time.Now())
+	return nil
+}
+
+// streamButtonTicks configures the button pins as digital interrupts and
+// streams their ticks into ticksChan.
+func (s *learningRoboticsLightSwitch) streamButtonTicks(ctx context.Context, b board.Board) error {
+	interrupts := []board.DigitalInterrupt{}
+	for _, button := range s.buttons {
+		interrupt, err := b.DigitalInterruptByName(button.pinName)
+		if err != nil {
+			return err
+		}
+		interrupts = append(interrupts, interrupt)
+	}
+	ticksChan := make(chan board.Tick, 16)
+	if err := b.StreamTicks(ctx, interrupts, ticksChan, map[string]interface{}{}); err != nil {
+		return err
+	}
+
+	for i, button := range s.buttons {
+		button.interrupt = interrupts[i]
+	}
+	s.ticksChan = ticksChan
+	return nil
+}
+
+// runInterrupts handles button ticks as they arrive. Ticks only arrive on
+// edges, so a timer wakes the loop to confirm debounced levels and fire long
+// presses and clicks that complete without a new edge.
+func (s *learningRoboticsLightSwitch) runInterrupts(ctx context.Context) error {
+	wake := time.NewTimer(time.Hour)
+	wake.Stop()
+	defer wake.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case tick, ok := <-s.ticksChan:
+			if !ok {
+				return errors.New("button tick stream closed")
+			}
+			for _, button := range s.buttons {
+				if tick.Name == button.interrupt.Name() {
+					s.sampleButton(ctx, button, !tick.High, time.Now())
+				}
 			}
-			if !lightOffValue {
-				s.lightOutputPin.Set(ctx, false, map[string]interface{}{})
+		case now := <-wake.C:
+			s.checkButtons(ctx, now)
+		}
+
+		var next time.Time
+		s.mu.Lock()
+		for _, button := range s.buttons {
+			for _, deadline := range button.deadlines() {
+				if next.IsZero() || deadline.Before(next) {
+					next = deadline
+				}
 			}
 		}
+		s.mu.Unlock()
+		if !next.IsZero() {
+			wake.Reset(time.Until(next))
+		}
+	}
+}
+
+// deadlines returns when the button next needs checking without a new sample.
+func (b *switchButton) deadlines() []time.Time {
+	var deadlines []time.Time
+	if deadline, ok := b.debouncedButton.deadline(); ok {
+		deadlines = append(deadlines, deadline)
+	}
+	if deadline, ok := b.gestures.deadline(); ok {
+		deadlines = append(deadlines, deadline)
+	}
+	return deadlines
+}
+
+// sampleButton feeds a raw button level into its debouncer and acts on any
+// gesture the resulting edge completes.
+func (s *learningRoboticsLightSwitch) sampleButton(ctx context.Context, button *switchButton, pressed bool, t time.Time) {
+	s.mu.Lock()
+	gesture := button.gestures.edge(button.sample(pressed, t), t)
+	s.mu.Unlock()
+	s.handleGesture(ctx, button, gesture)
+}
+
+// checkButtons confirms pending levels that have been stable for the debounce
+// window and fires gestures that complete with time alone.
+func (s *learningRoboticsLightSwitch) checkButtons(ctx context.Context, t time.Time) {
+	for _, button := range s.buttons {
+		s.mu.Lock()
+		pending, candidate := button.pending, button.candidate
+		s.mu.Unlock()
+		if pending {
+			s.sampleButton(ctx, button, candidate, t)
+		}
+
+		s.mu.Lock()
+		gesture := button.gestures.tick(t)
+		s.mu.Unlock()
+		s.handleGesture(ctx, button, gesture)
+	}
+}
+
+// handleGesture performs the light action the button maps the gesture to.
+func (s *learningRoboticsLightSwitch) handleGesture(ctx context.Context, button *switchButton, gesture buttonGesture) {
+	action, ok := button.actions[gesture]
+	if !ok {
+		return
+	}
+	binding := button.binding
+	_, err := s.applyAction(ctx, binding, action, lightSourceButton)
+	if errors.Is(err, errOutsideSchedule) {
+		s.logger.Infof("ignoring the %s button of %s outside its schedule", button.label, binding.name)
+		return
+	}
+	if err == nil {
+		s.health.ok()
+	} else if ctx.Err() == nil {
+		s.health.fail(err)
+		s.logger.Errorf("failed to turn %s %s after a %s of the %s button: %v", binding.name, action, gesture, button.label, err)
+	}
+}
+
+// applyAction turns a binding's light on, off or toggles it on behalf of
+// source and returns whether the light is now on. Buttons and remote commands
+// go through here, so whichever acts last wins.
+func (s *learningRoboticsLightSwitch) applyAction(ctx context.Context, binding *lightBinding, action, source string) (bool, error) {
+	binding.changeMu.Lock()
+	defer binding.changeMu.Unlock()
+
+	s.mu.Lock()
+	was := binding.lightOn
+	s.mu.Unlock()
+	if !was && action != lightActionOff && !scheduleAllows(binding.schedule, time.Now()) {
+		return was, errOutsideSchedule
+	}
+	on, err := binding.light.apply(ctx, action, was)
+	if err != nil {
+		return was, err
+	}
+	s.mu.Lock()
+	binding.lightOn = on
+	binding.changedBy = source
+	binding.changed = time.Now()
+	binding.autoOffAt = time.Time{}
+	if on && binding.autoOffAfter > 0 {
+		binding.autoOffAt = binding.changed.Add(binding.autoOffAfter)
+	}
+	s.mu.Unlock()
+	return on, nil
+}
+
+// runAutoOff turns lights off once their autoOffAt passes.
+func (s *learningRoboticsLightSwitch) runAutoOff(ctx context.Context) {
+	ticker := time.NewTicker(autoOffCheckInterval)
+	defer ticker.Stop()
+
+	backoffs := make([]retryBackoff, len(s.bindings))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for i, binding := range s.bindings {
+				s.mu.Lock()
+				due := !binding.autoOffAt.IsZero() && !now.Before(binding.autoOffAt)
+				s.mu.Unlock()
+				if !due {
+					continue
+				}
+				_, err := s.applyAction(ctx, binding, lightActionOff, lightSourceAutoOff)
+				if err == nil {
+					backoffs[i].reset()
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				wait := backoffs[i].wait()
+				s.health.fail(err)
+				s.logger.Errorf("failed to turn %s off automatically, retrying in %v: %v", binding.name, wait, err)
+				s.mu.Lock()
+				if !binding.autoOffAt.IsZero() {
+					binding.autoOffAt = now.Add(wait)
+				}
+				s.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (s *learningRoboticsLightSwitch) getState() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	input := "polling"
+	if s.ticksChan != nil {
+		input = "interrupts"
+	}
+	bindings := map[string]any{}
+	for _, binding := range s.bindings {
+		bindings[binding.name] = binding.state()
+	}
+	state := map[string]any{
+		"input":    input,
+		"health":   s.health.state(),
+		"bindings": bindings,
+	}
+	if len(s.cfg.Bindings) == 0 {
+		// a single light configured at the top level also reports its state
+		// at the top level
+		for key, value := range s.bindings[0].state() {
+			state[key] = value
+		}
 	}
+	return state
 }
@@ -0,0 +1,99 @@
+package learningrobotics
+
+import (
+	"sync"
+	"time"
+)
+
+// health statuses of a background loop
+const (
+	healthRunning  = "running"
+	healthDegraded = "degraded"
+	healthStopped  = "stopped"
+)
+
+const (
+	retryBackoffMin = 100 * time.Millisecond
+	retryBackoffMax = 5 * time.Second
+)
+
+// loopHealth tracks whether a background loop is working and the last error
+// it hit. It is safe for concurrent use.
+type loopHealth struct {
+	mu          sync.Mutex
+	status      string
+	errors      int
+	lastError   error
+	lastErrorAt time.Time
+}
+
+func newLoopHealth() *loopHealth {
+	return &loopHealth{status: healthRunning}
+}
+
+// fail records err and marks the loop degraded.
+func (h *loopHealth) fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status != healthStopped {
+		h.status = healthDegraded
+	}
+	h.errors++
+	h.lastError = err
+	h.lastErrorAt = time.Now()
+}
+
+// ok marks the loop running again after it recovers. The last error is kept
+// for diagnostics.
+func (h *loopHealth) ok() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status == healthDegraded {
+		h.status = healthRunning
+	}
+}
+
+func (h *loopHealth) stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status = healthStopped
+}
+
+func (h *loopHealth) state() map[string]any {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := map[string]any{
+		"status": h.status,
+		"errors": h.errors,
+	}
+	if h.lastError != nil {
+		state["last_error"] = h.lastError.Error()
+		state["last_error_at"] = h.lastErrorAt.Format(time.RFC3339Nano)
+	}
+	return state
+}
+
+// retryBackoff doubles the wait between retries after each consecutive
+// failure, from retryBackoffMin up to retryBackoffMax.
+type retryBackoff struct {
+	next time.Duration
+}
+
+// wait returns how long to wait before the next retry.
+func (b *retryBackoff) wait() time.Duration {
+	if b.next == 0 {
+		b.next = retryBackoffMin
+	}
+	wait := b.next
+	b.next = min(b.next*2, retryBackoffMax)
+	return wait
+}
+
+// failing reports whether wait has been called since the last reset.
+func (b *retryBackoff) failing() bool {
+	return b.next != 0
+}
+
+func (b *retryBackoff) reset() {
+	b.next = 0
+}
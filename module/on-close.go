@@ -0,0 +1,73 @@
+package learningrobotics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.viam.com/rdk/logging"
+)
+
+// on_close policies shared by every model that drives outputs.
+const (
+	// onCloseOff turns the outputs off. It is the default.
+	onCloseOff = "off"
+	// onCloseHold leaves the outputs as they were.
+	onCloseHold = "hold"
+	// onCloseSet sets the outputs to the model's on_close_value.
+	onCloseSet = "set"
+)
+
+const (
+	// closeTimeout bounds how long Close spends applying the on_close policy.
+	closeTimeout = 2 * time.Second
+	// closeRetryInterval is the wait between attempts to apply the policy.
+	closeRetryInterval = 100 * time.Millisecond
+)
+
+// validateOnClose checks an on_close policy. hasValue reports whether the
+// model's on_close_value is set, which the set policy requires.
+func validateOnClose(policy string, hasValue bool) error {
+	switch policy {
+	case "", onCloseOff, onCloseHold:
+		return nil
+	case onCloseSet:
+		if !hasValue {
+			return fmt.Errorf("on_close_value is required when on_close is %q", onCloseSet)
+		}
+		return nil
+	default:
+		return fmt.Errorf("on_close must be one of %q, %q or %q, got %q", onCloseOff, onCloseHold, onCloseSet, policy)
+	}
+}
+
+// applyOnClose runs apply for the policy, retrying until it succeeds or
+// closeTimeout passes. Failures are logged as well as returned so that they
+// are visible even when the caller of Close ignores the error.
+func applyOnClose(
+	ctx context.Context,
+	logger logging.Logger,
+	policy string,
+	apply func(ctx context.Context, policy string) error,
+) error {
+	if policy == "" {
+		policy = onCloseOff
+	}
+	if policy == onCloseHold {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, closeTimeout)
+	defer cancel()
+	for {
+		err := apply(ctx, policy)
+		if err == nil {
+			return nil
+		}
+		if sleepContext(ctx, closeRetryInterval) != nil {
+			logger.Errorf("failed to apply on_close policy %q: %v", policy, err)
+			return fmt.Errorf("applying on_close policy %q: %w", policy, err)
+		}
+		logger.Warnf("retrying on_close policy %q after error: %v", policy, err)
+	}
+}
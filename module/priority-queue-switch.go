@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"slices"
-	"strconv"
 	"sync"
 	"time"
 
@@ -107,17 +106,15 @@ func (s *priorityQueueSwitchPriorityQueueSwitch) DoCommand(ctx context.Context,
 		return map[string]any{"length": s.pq.Len()}, nil
 	}
 
-	label, ok := cmd["label"].(string)
-	if !ok {
-		return nil, errors.New("label is required")
-	}
-	priorityStr, ok := cmd["priority"].(string)
-	if !ok {
-		return nil, errors.New("priority is required")
+	args := newCommandArgs("", cmd)
+	label, err := args.requiredStringArg("label")
+	if err != nil {
+		return nil, err
 	}
-	priority, err := strconv.Atoi(priorityStr)
+	// priority was originally sent as a string such as "1", which is still accepted
+	priority, err := args.requiredIntArg("priority")
 	if err != nil {
-		return nil, errors.New("priority must be an integer")
+		return nil, err
 	}
 
 	s.mu.Lock()
@@ -0,0 +1,129 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RGBCalibration corrects colors for the LED's non-linear brightness and
+// uneven channel intensities. Unset fields leave the output unchanged.
+type RGBCalibration struct {
+	Red   *float64 `json:"red,omitempty"`
+	Green *float64 `json:"green,omitempty"`
+	Blue  *float64 `json:"blue,omitempty"`
+	Gamma *float64 `json:"gamma,omitempty"`
+}
+
+// Validate ensures the scale factors and gamma are within usable ranges.
+func (cfg *RGBCalibration) Validate() error {
+	for _, channel := range []struct {
+		name  string
+		scale *float64
+	}{
+		{"red", cfg.Red},
+		{"green", cfg.Green},
+		{"blue", cfg.Blue},
+	} {
+		if channel.scale != nil && (*channel.scale < 0 || *channel.scale > 1) {
+			return fmt.Errorf("calibration.%s must be between 0 and 1, got %v", channel.name, *channel.scale)
+		}
+	}
+	if cfg.Gamma != nil && (*cfg.Gamma <= 0 || *cfg.Gamma > 5) {
+		return fmt.Errorf("calibration.gamma must be greater than 0 and at most 5, got %v", *cfg.Gamma)
+	}
+	return nil
+}
+
+// colorCalibration is the resolved calibration applied to every PWM color.
+type colorCalibration struct {
+	red   float64
+	green float64
+	blue  float64
+	gamma float64
+}
+
+var identityCalibration = colorCalibration{red: 1, green: 1, blue: 1, gamma: 1}
+
+// resolve fills unset fields of cfg from base.
+func (cfg *RGBCalibration) resolve(base colorCalibration) colorCalibration {
+	if cfg == nil {
+		return base
+	}
+	if cfg.Red != nil {
+		base.red = *cfg.Red
+	}
+	if cfg.Green != nil {
+		base.green = *cfg.Green
+	}
+	if cfg.Blue != nil {
+		base.blue = *cfg.Blue
+	}
+	if cfg.Gamma != nil {
+		base.gamma = *cfg.Gamma
+	}
+	return base
+}
+
+// dutyCycles converts a color dimmed to brightness into PWM duty cycles using
+// the gamma curve and channel scale factors.
+func (c colorCalibration) dutyCycles(color rgbColor, brightness float64) (float64, float64, float64) {
+	red, green, blue := color.dutyCycles()
+	red, green, blue = red*brightness, green*brightness, blue*brightness
+	return c.red * math.Pow(red, c.gamma),
+		c.green * math.Pow(green, c.gamma),
+		c.blue * math.Pow(blue, c.gamma)
+}
+
+func (c colorCalibration) toMap() map[string]any {
+	return map[string]any{
+		"red":   c.red,
+		"green": c.green,
+		"blue":  c.blue,
+		"gamma": c.gamma,
+	}
+}
+
+// parseCalibration reads calibration overrides from a DoCommand argument.
+func parseCalibration(args commandArgs) (*RGBCalibration, error) {
+	cfg := &RGBCalibration{}
+	fields := map[string]**float64{
+		"red":   &cfg.Red,
+		"green": &cfg.Green,
+		"blue":  &cfg.Blue,
+		"gamma": &cfg.Gamma,
+	}
+	for key := range args.values {
+		field, ok := fields[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown calibration field %q", args.field(key))
+		}
+		value, err := args.requiredFloatArg(key)
+		if err != nil {
+			return nil, err
+		}
+		*field = &value
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// calibrationSweep shows white at decreasing levels followed by each primary
+// at full intensity, holding each step so the LED can be compared by eye.
+func calibrationSweep(step time.Duration) []keyframe {
+	var keyframes []keyframe
+	for _, level := range []float64{1, 0.75, 0.5, 0.25, 0.1} {
+		keyframes = append(keyframes, keyframe{color: colorWhite.scale(level)})
+	}
+	keyframes = append(keyframes,
+		keyframe{color: colorRed},
+		keyframe{color: colorGreen},
+		keyframe{color: colorBlue},
+	)
+	for i := range keyframes {
+		keyframes[i].duration = step
+	}
+	return keyframes
+}
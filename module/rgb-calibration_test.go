@@ -0,0 +1,129 @@
+package learningrobotics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalibrationDutyCycles(t *testing.T) {
+	tests := []struct {
+		name        string
+		calibration colorCalibration
+		color       rgbColor
+		brightness  float64
+		want        [3]float64
+	}{
+		{name: "identity", calibration: identityCalibration, color: rgbColor{Red: 255, Blue: 51}, brightness: 1, want: [3]float64{1, 0, 0.2}},
+		{name: "brightness", calibration: identityCalibration, color: colorWhite, brightness: 0.5, want: [3]float64{0.5, 0.5, 0.5}},
+		{
+			name:        "gamma",
+			calibration: colorCalibration{red: 1, green: 1, blue: 1, gamma: 2},
+			color:       colorWhite,
+			brightness:  0.5,
+			want:        [3]float64{0.25, 0.25, 0.25},
+		},
+		{
+			name:        "channel scale",
+			calibration: colorCalibration{red: 1, green: 0.5, blue: 0.25, gamma: 1},
+			color:       colorWhite,
+			brightness:  1,
+			want:        [3]float64{1, 0.5, 0.25},
+		},
+		{
+			name:        "scale after gamma",
+			calibration: colorCalibration{red: 0.5, green: 1, blue: 1, gamma: 2},
+			color:       rgbColor{Red: 255},
+			brightness:  0.5,
+			want:        [3]float64{0.125, 0, 0},
+		},
+		{
+			name:        "off stays off",
+			calibration: colorCalibration{red: 0.8, green: 0.8, blue: 0.8, gamma: 2.2},
+			color:       colorOff,
+			brightness:  1,
+			want:        [3]float64{0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			red, green, blue := tt.calibration.dutyCycles(tt.color, tt.brightness)
+			got := [3]float64{red, green, blue}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("dutyCycles(%v, %v) = %v, want %v", tt.color, tt.brightness, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCalibration(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]any
+		base    colorCalibration
+		want    colorCalibration
+		wantErr string
+	}{
+		{name: "empty", values: map[string]any{}, base: identityCalibration, want: identityCalibration},
+		{
+			name:   "overrides",
+			values: map[string]any{"green": 0.5, "gamma": "2.2"},
+			base:   identityCalibration,
+			want:   colorCalibration{red: 1, green: 0.5, blue: 1, gamma: 2.2},
+		},
+		{
+			name:   "keeps unset fields",
+			values: map[string]any{"blue": 0},
+			base:   colorCalibration{red: 0.9, green: 0.8, blue: 0.7, gamma: 2},
+			want:   colorCalibration{red: 0.9, green: 0.8, gamma: 2},
+		},
+		{
+			name:    "unknown field",
+			values:  map[string]any{"white": 1.0},
+			wantErr: `unknown calibration field "calibrate.white"`,
+		},
+		{
+			name:    "scale above 1",
+			values:  map[string]any{"red": 1.5},
+			wantErr: "calibration.red must be between 0 and 1, got 1.5",
+		},
+		{
+			name:    "negative scale",
+			values:  map[string]any{"blue": -0.25},
+			wantErr: "calibration.blue must be between 0 and 1, got -0.25",
+		},
+		{
+			name:    "zero gamma",
+			values:  map[string]any{"gamma": 0.0},
+			wantErr: "calibration.gamma must be greater than 0 and at most 5, got 0",
+		},
+		{
+			name:    "gamma above 5",
+			values:  map[string]any{"gamma": 6.0},
+			wantErr: "calibration.gamma must be greater than 0 and at most 5, got 6",
+		},
+		{
+			name:    "not a number",
+			values:  map[string]any{"green": "bright"},
+			wantErr: "calibrate.green must be a number, got bright",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseCalibration(newCommandArgs("calibrate", tt.values))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseCalibration() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCalibration() failed: %v", err)
+			}
+			if got := cfg.resolve(tt.base); got != tt.want {
+				t.Errorf("resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
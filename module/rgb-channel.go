@@ -0,0 +1,55 @@
+package learningrobotics
+
+import (
+	"context"
+
+	"go.viam.com/rdk/components/board"
+)
+
+// ledChannel is one color channel of an RGB LED. Inverted channels are driven
+// low to turn on, as with common-anode LEDs.
+type ledChannel struct {
+	pin      board.GPIOPin
+	inverted bool
+}
+
+// newLedChannel looks up the pin for a channel. A per-channel invert setting
+// overrides the LED-wide active_low setting.
+func newLedChannel(b board.Board, pinName string, activeLow bool, invert *bool) (ledChannel, error) {
+	pin, err := b.GPIOPinByName(pinName)
+	if err != nil {
+		return ledChannel{}, err
+	}
+	inverted := activeLow
+	if invert != nil {
+		inverted = *invert
+	}
+	return ledChannel{pin: pin, inverted: inverted}, nil
+}
+
+// set turns the channel fully on or off.
+func (c ledChannel) set(ctx context.Context, on bool) error {
+	return c.pin.Set(ctx, on != c.inverted, map[string]interface{}{})
+}
+
+// setDuty drives the channel with a PWM duty cycle where 1 is fully on.
+func (c ledChannel) setDuty(ctx context.Context, duty float64) error {
+	if c.inverted {
+		duty = 1 - duty
+	}
+	return c.pin.SetPWM(ctx, duty, map[string]interface{}{})
+}
+
+// readDuty returns the channel's PWM duty cycle read back from the board,
+// where 1 is fully on. Digital outputs are not read back, since reading a
+// GPIO pin on some boards reconfigures it as an input.
+func (c ledChannel) readDuty(ctx context.Context) (float64, error) {
+	duty, err := c.pin.PWM(ctx, map[string]interface{}{})
+	if err != nil {
+		return 0, err
+	}
+	if c.inverted {
+		duty = 1 - duty
+	}
+	return duty, nil
+}
@@ -0,0 +1,164 @@
+package learningrobotics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// rgbColor is an 8-bit per channel color as shown on an RGB LED.
+type rgbColor struct {
+	Red   uint8
+	Green uint8
+	Blue  uint8
+}
+
+var (
+	colorOff   = rgbColor{}
+	colorRed   = rgbColor{Red: 255}
+	colorGreen = rgbColor{Green: 255}
+	colorBlue  = rgbColor{Blue: 255}
+	colorWhite = rgbColor{Red: 255, Green: 255, Blue: 255}
+)
+
+// Hex returns the color formatted as "#rrggbb".
+func (c rgbColor) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.Red, c.Green, c.Blue)
+}
+
+// dutyCycles converts each channel to a PWM duty cycle between 0 and 1.
+func (c rgbColor) dutyCycles() (float64, float64, float64) {
+	return float64(c.Red) / 255, float64(c.Green) / 255, float64(c.Blue) / 255
+}
+
+// scale multiplies every channel by factor, which is clamped to [0, 1].
+func (c rgbColor) scale(factor float64) rgbColor {
+	factor = math.Max(0, math.Min(1, factor))
+	return rgbColor{
+		Red:   uint8(math.Round(float64(c.Red) * factor)),
+		Green: uint8(math.Round(float64(c.Green) * factor)),
+		Blue:  uint8(math.Round(float64(c.Blue) * factor)),
+	}
+}
+
+// blend linearly interpolates from c towards other; t of 0 is c and 1 is other.
+func (c rgbColor) blend(other rgbColor, t float64) rgbColor {
+	t = math.Max(0, math.Min(1, t))
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	return rgbColor{
+		Red:   mix(c.Red, other.Red),
+		Green: mix(c.Green, other.Green),
+		Blue:  mix(c.Blue, other.Blue),
+	}
+}
+
+// hsvToRGB converts a hue in degrees and saturation and value in [0, 1] to a color.
+func hsvToRGB(hue, saturation, value float64) rgbColor {
+	hue = math.Mod(hue, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := value - chroma
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = chroma, x, 0
+	case hue < 120:
+		r, g, b = x, chroma, 0
+	case hue < 180:
+		r, g, b = 0, chroma, x
+	case hue < 240:
+		r, g, b = 0, x, chroma
+	case hue < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	return rgbColor{
+		Red:   uint8(math.Round((r + m) * 255)),
+		Green: uint8(math.Round((g + m) * 255)),
+		Blue:  uint8(math.Round((b + m) * 255)),
+	}
+}
+
+// parseHexColor parses "#rrggbb", "rrggbb" or the short "#rgb" form.
+func parseHexColor(hex string) (rgbColor, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return rgbColor{}, fmt.Errorf("invalid hex color %q: expected #rrggbb", hex)
+	}
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("invalid hex color %q: %v", hex, err)
+	}
+	return rgbColor{
+		Red:   uint8(value >> 16),
+		Green: uint8(value >> 8),
+		Blue:  uint8(value),
+	}, nil
+}
+
+// parseColor accepts either a hex string or a map with red, green and blue
+// channels in the range 0-255. Field names the argument in error messages.
+func parseColor(field string, value any) (rgbColor, error) {
+	switch v := value.(type) {
+	case string:
+		return parseHexColor(v)
+	case map[string]any:
+		args := newCommandArgs(field, v)
+		if args.has("hex") {
+			hex, err := args.requiredStringArg("hex")
+			if err != nil {
+				return rgbColor{}, err
+			}
+			return parseHexColor(hex)
+		}
+		var channels [3]uint8
+		for i, key := range []string{"red", "green", "blue"} {
+			channel, err := args.intArg(key, 0)
+			if err != nil {
+				return rgbColor{}, err
+			}
+			if err := args.checkRange(key, float64(channel), 0, 255); err != nil {
+				return rgbColor{}, err
+			}
+			channels[i] = uint8(channel)
+		}
+		return rgbColor{Red: channels[0], Green: channels[1], Blue: channels[2]}, nil
+	default:
+		return rgbColor{}, fmt.Errorf("%s must be a hex string or a map of red, green and blue", field)
+	}
+}
+
+// parseHSV reads hue in degrees and saturation and value in [0, 1], which
+// default to 1.
+func parseHSV(args commandArgs) (rgbColor, error) {
+	hue, err := args.requiredFloatArg("hue")
+	if err != nil {
+		return rgbColor{}, err
+	}
+	saturation, err := args.floatArg("saturation", 1)
+	if err != nil {
+		return rgbColor{}, err
+	}
+	if err := args.checkRange("saturation", saturation, 0, 1); err != nil {
+		return rgbColor{}, err
+	}
+	value, err := args.floatArg("value", 1)
+	if err != nil {
+		return rgbColor{}, err
+	}
+	if err := args.checkRange("value", value, 0, 1); err != nil {
+		return rgbColor{}, err
+	}
+	return hsvToRGB(hue, saturation, value), nil
+}
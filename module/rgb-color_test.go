@@ -0,0 +1,193 @@
+package learningrobotics
+
+import (
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		hex     string
+		want    rgbColor
+		wantErr bool
+	}{
+		{hex: "#ff8800", want: rgbColor{Red: 255, Green: 136, Blue: 0}},
+		{hex: "ff8800", want: rgbColor{Red: 255, Green: 136, Blue: 0}},
+		{hex: "#FF8800", want: rgbColor{Red: 255, Green: 136, Blue: 0}},
+		{hex: " #102030 ", want: rgbColor{Red: 16, Green: 32, Blue: 48}},
+		{hex: "#f80", want: rgbColor{Red: 255, Green: 136, Blue: 0}},
+		{hex: "abc", want: rgbColor{Red: 170, Green: 187, Blue: 204}},
+		{hex: "#000000", want: colorOff},
+		{hex: "", wantErr: true},
+		{hex: "#", wantErr: true},
+		{hex: "#ff88", wantErr: true},
+		{hex: "#ff88001", wantErr: true},
+		{hex: "#gg8800", wantErr: true},
+		{hex: "#-f8800", wantErr: true},
+		{hex: "red", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hex, func(t *testing.T) {
+			got, err := parseHexColor(tt.hex)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseHexColor(%q) = %v, want error", tt.hex, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHexColor(%q) failed: %v", tt.hex, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseHexColor(%q) = %v, want %v", tt.hex, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    rgbColor
+		wantErr string
+	}{
+		{name: "hex string", value: "#00ff00", want: colorGreen},
+		{name: "hex field", value: map[string]any{"hex": "#0000ff"}, want: colorBlue},
+		{
+			name:  "channels",
+			value: map[string]any{"red": 255.0, "green": "136", "blue": 0},
+			want:  rgbColor{Red: 255, Green: 136},
+		},
+		{name: "missing channels are 0", value: map[string]any{"red": 10.0}, want: rgbColor{Red: 10}},
+		{name: "channel limits", value: map[string]any{"red": 0.0, "green": 255.0}, want: colorGreen},
+		{
+			name:    "channel above 255",
+			value:   map[string]any{"red": 256.0},
+			wantErr: "color.red must be between 0 and 255, got 256",
+		},
+		{
+			name:    "negative channel",
+			value:   map[string]any{"blue": -1.0},
+			wantErr: "color.blue must be between 0 and 255, got -1",
+		},
+		{
+			name:    "fractional channel",
+			value:   map[string]any{"green": 12.5},
+			wantErr: "color.green must be an integer, got 12.5",
+		},
+		{
+			name:    "hex field must be a string",
+			value:   map[string]any{"hex": 255.0},
+			wantErr: "color.hex must be a string, got 255",
+		},
+		{
+			name:    "bad hex",
+			value:   "#12345",
+			wantErr: `invalid hex color "#12345": expected #rrggbb`,
+		},
+		{
+			name:    "wrong type",
+			value:   7.0,
+			wantErr: "color must be a hex string or a map of red, green and blue",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColor("color", tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseColor(%v) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseColor(%v) failed: %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseColor(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorHex(t *testing.T) {
+	for _, c := range []rgbColor{colorOff, colorWhite, {Red: 1, Green: 2, Blue: 171}} {
+		parsed, err := parseHexColor(c.Hex())
+		if err != nil {
+			t.Fatalf("parseHexColor(%q) failed: %v", c.Hex(), err)
+		}
+		if parsed != c {
+			t.Errorf("%v round trips through %q as %v", c, c.Hex(), parsed)
+		}
+	}
+}
+
+func TestHSVToRGB(t *testing.T) {
+	tests := []struct {
+		name                   string
+		hue, saturation, value float64
+		want                   rgbColor
+	}{
+		{name: "red", hue: 0, saturation: 1, value: 1, want: colorRed},
+		{name: "yellow", hue: 60, saturation: 1, value: 1, want: rgbColor{Red: 255, Green: 255}},
+		{name: "green", hue: 120, saturation: 1, value: 1, want: colorGreen},
+		{name: "cyan", hue: 180, saturation: 1, value: 1, want: rgbColor{Green: 255, Blue: 255}},
+		{name: "blue", hue: 240, saturation: 1, value: 1, want: colorBlue},
+		{name: "magenta", hue: 300, saturation: 1, value: 1, want: rgbColor{Red: 255, Blue: 255}},
+		{name: "orange", hue: 30, saturation: 1, value: 1, want: rgbColor{Red: 255, Green: 128}},
+		{name: "360 wraps to red", hue: 360, saturation: 1, value: 1, want: colorRed},
+		{name: "480 wraps to green", hue: 480, saturation: 1, value: 1, want: colorGreen},
+		{name: "negative hue wraps", hue: -120, saturation: 1, value: 1, want: colorBlue},
+		{name: "-360 wraps to red", hue: -360, saturation: 1, value: 1, want: colorRed},
+		{name: "no saturation is grey", hue: 200, saturation: 0, value: 0.5, want: rgbColor{Red: 128, Green: 128, Blue: 128}},
+		{name: "no value is off", hue: 90, saturation: 1, value: 0, want: colorOff},
+		{name: "half saturation", hue: 0, saturation: 0.5, value: 1, want: rgbColor{Red: 255, Green: 128, Blue: 128}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hsvToRGB(tt.hue, tt.saturation, tt.value); got != tt.want {
+				t.Errorf("hsvToRGB(%v, %v, %v) = %v, want %v", tt.hue, tt.saturation, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]any
+		want    rgbColor
+		wantErr string
+	}{
+		{name: "defaults to full saturation and value", values: map[string]any{"hue": 120.0}, want: colorGreen},
+		{name: "wrapped hue", values: map[string]any{"hue": "-120"}, want: colorBlue},
+		{name: "missing hue", values: map[string]any{}, wantErr: "hsv.hue is required"},
+		{
+			name:    "saturation above 1",
+			values:  map[string]any{"hue": 0.0, "saturation": 1.5},
+			wantErr: "hsv.saturation must be between 0 and 1, got 1.5",
+		},
+		{
+			name:    "negative value",
+			values:  map[string]any{"hue": 0.0, "value": -0.5},
+			wantErr: "hsv.value must be between 0 and 1, got -0.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHSV(newCommandArgs("hsv", tt.values))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseHSV() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHSV() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseHSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
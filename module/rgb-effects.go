@@ -0,0 +1,372 @@
+package learningrobotics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// effectFrameInterval is how often smooth effects render a new frame (50 fps).
+const effectFrameInterval = time.Second / 50
+
+// maxEffectDurationMs caps effect durations at an hour, well short of the
+// values that overflow a time.Duration.
+const maxEffectDurationMs = 60 * 60 * 1000
+
+// rgbEffect is an animation that renders colors onto an RGB LED until it
+// finishes or its context is cancelled.
+type rgbEffect interface {
+	Name() string
+	Run(ctx context.Context, render func(context.Context, rgbColor) error) error
+}
+
+// newEffect builds an effect from the arguments of a start_effect command.
+func newEffect(args commandArgs) (rgbEffect, error) {
+	name, err := args.requiredStringArg("name")
+	if err != nil {
+		return nil, err
+	}
+	p := effectParams{args: newCommandArgs(name, args.values)}
+
+	switch name {
+	case "party":
+		// party_mode has always called its repeat count occurences
+		repeatKey := "repeat"
+		if args.has("occurences") {
+			repeatKey = "occurences"
+		}
+		repeat, err := p.integer(repeatKey, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &partyEffect{repeat: repeat}, nil
+	case "breathe":
+		color, err := p.color("color", nil)
+		if err != nil {
+			return nil, err
+		}
+		period, err := p.duration("period_ms", 2000, 100)
+		if err != nil {
+			return nil, err
+		}
+		repeat, err := p.integer("repeat", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &breatheEffect{color: color, period: period, repeat: repeat}, nil
+	case "blink":
+		color, err := p.color("color", nil)
+		if err != nil {
+			return nil, err
+		}
+		on, err := p.duration("on_ms", 500, 10)
+		if err != nil {
+			return nil, err
+		}
+		off, err := p.duration("off_ms", 500, 10)
+		if err != nil {
+			return nil, err
+		}
+		repeat, err := p.integer("repeat", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &blinkEffect{name: name, color: color, on: on, off: off, repeat: repeat}, nil
+	case "strobe":
+		color, err := p.color("color", &colorWhite)
+		if err != nil {
+			return nil, err
+		}
+		period, err := p.duration("period_ms", 100, 20)
+		if err != nil {
+			return nil, err
+		}
+		flash, err := p.duration("flash_ms", 10, 1)
+		if err != nil {
+			return nil, err
+		}
+		if flash >= period {
+			return nil, fmt.Errorf("%s must be shorter than period_ms", p.args.field("flash_ms"))
+		}
+		repeat, err := p.integer("repeat", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &blinkEffect{name: name, color: color, on: flash, off: period - flash, repeat: repeat}, nil
+	case "rainbow":
+		period, err := p.duration("period_ms", 5000, 100)
+		if err != nil {
+			return nil, err
+		}
+		repeat, err := p.integer("repeat", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &rainbowEffect{period: period, repeat: repeat}, nil
+	case "sequence":
+		keyframes, err := p.keyframes("keyframes")
+		if err != nil {
+			return nil, err
+		}
+		repeat, err := p.integer("repeat", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &sequenceEffect{name: name, keyframes: keyframes, repeat: repeat}, nil
+	default:
+		return nil, fmt.Errorf("unknown effect %q: expected one of party, breathe, blink, strobe, rainbow or sequence", name)
+	}
+}
+
+// effectParams reads and validates the parameters of a single effect. Errors
+// name the effect and the offending field, e.g. "breathe.period_ms".
+type effectParams struct {
+	args commandArgs
+}
+
+func (p effectParams) integer(key string, def, min int) (int, error) {
+	value, err := p.args.intArg(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.args.checkMin(key, value, min); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func (p effectParams) duration(key string, defMs, minMs int) (time.Duration, error) {
+	ms, err := p.integer(key, defMs, minMs)
+	if err != nil {
+		return 0, err
+	}
+	if ms > maxEffectDurationMs {
+		return 0, fmt.Errorf("%s must be at most %d, got %d", p.args.field(key), maxEffectDurationMs, ms)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (p effectParams) color(key string, def *rgbColor) (rgbColor, error) {
+	if !p.args.has(key) && def != nil {
+		return *def, nil
+	}
+	raw, err := p.args.required(key)
+	if err != nil {
+		return rgbColor{}, err
+	}
+	return parseColor(p.args.field(key), raw)
+}
+
+func (p effectParams) keyframes(key string) ([]keyframe, error) {
+	raw, ok := p.args.values[key].([]any)
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("%s must be a non-empty list", p.args.field(key))
+	}
+	keyframes := make([]keyframe, 0, len(raw))
+	for i, item := range raw {
+		values, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be a map", p.args.field(key), i)
+		}
+		frame := effectParams{args: newCommandArgs(fmt.Sprintf("%s[%d]", p.args.field(key), i), values)}
+		color, err := frame.color("color", nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := frame.args.required("duration_ms"); err != nil {
+			return nil, err
+		}
+		duration, err := frame.duration("duration_ms", 0, 10)
+		if err != nil {
+			return nil, err
+		}
+		fade, err := frame.args.boolArg("fade", false)
+		if err != nil {
+			return nil, err
+		}
+		keyframes = append(keyframes, keyframe{color: color, duration: duration, fade: fade})
+	}
+	return keyframes, nil
+}
+
+// repeatCycles runs cycle repeat times, or until cancelled when repeat is zero,
+// then turns the LED off.
+func repeatCycles(
+	ctx context.Context,
+	repeat int,
+	render func(context.Context, rgbColor) error,
+	cycle func() error,
+) error {
+	for i := 0; repeat == 0 || i < repeat; i++ {
+		if err := cycle(); err != nil {
+			return err
+		}
+	}
+	return render(ctx, colorOff)
+}
+
+// animate calls frame with the elapsed fraction of duration at the effect frame rate.
+func animate(ctx context.Context, duration time.Duration, frame func(progress float64) error) error {
+	start := time.Now()
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= duration {
+			return nil
+		}
+		if err := frame(float64(elapsed) / float64(duration)); err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, effectFrameInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// partyEffect cycles red, green and blue.
+type partyEffect struct {
+	repeat int
+}
+
+func (e *partyEffect) Name() string {
+	return "party"
+}
+
+func (e *partyEffect) Run(ctx context.Context, render func(context.Context, rgbColor) error) error {
+	return repeatCycles(ctx, e.repeat, render, func() error {
+		for _, color := range []rgbColor{colorRed, colorGreen, colorBlue} {
+			if err := render(ctx, color); err != nil {
+				return err
+			}
+			if err := sleepContext(ctx, time.Millisecond*100); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// breatheEffect smoothly fades a color in and out once per period.
+type breatheEffect struct {
+	color  rgbColor
+	period time.Duration
+	repeat int
+}
+
+func (e *breatheEffect) Name() string {
+	return "breathe"
+}
+
+func (e *breatheEffect) Run(ctx context.Context, render func(context.Context, rgbColor) error) error {
+	return repeatCycles(ctx, e.repeat, render, func() error {
+		return animate(ctx, e.period, func(progress float64) error {
+			return render(ctx, e.color.scale((1-math.Cos(2*math.Pi*progress))/2))
+		})
+	})
+}
+
+// blinkEffect alternates a color with off. Strobe is a blink with a short flash.
+type blinkEffect struct {
+	name   string
+	color  rgbColor
+	on     time.Duration
+	off    time.Duration
+	repeat int
+}
+
+func (e *blinkEffect) Name() string {
+	return e.name
+}
+
+func (e *blinkEffect) Run(ctx context.Context, render func(context.Context, rgbColor) error) error {
+	return repeatCycles(ctx, e.repeat, render, func() error {
+		if err := render(ctx, e.color); err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, e.on); err != nil {
+			return err
+		}
+		if err := render(ctx, colorOff); err != nil {
+			return err
+		}
+		return sleepContext(ctx, e.off)
+	})
+}
+
+// rainbowEffect rotates through every hue once per period.
+type rainbowEffect struct {
+	period time.Duration
+	repeat int
+}
+
+func (e *rainbowEffect) Name() string {
+	return "rainbow"
+}
+
+func (e *rainbowEffect) Run(ctx context.Context, render func(context.Context, rgbColor) error) error {
+	return repeatCycles(ctx, e.repeat, render, func() error {
+		return animate(ctx, e.period, func(progress float64) error {
+			return render(ctx, hsvToRGB(progress*360, 1, 1))
+		})
+	})
+}
+
+// keyframe is a single step of a sequence effect. When fade is set the color
+// is reached gradually over the duration, otherwise it is held for the duration.
+type keyframe struct {
+	color    rgbColor
+	duration time.Duration
+	fade     bool
+}
+
+// sequenceEffect plays a user-defined list of keyframes.
+type sequenceEffect struct {
+	name      string
+	keyframes []keyframe
+	repeat    int
+}
+
+func (e *sequenceEffect) Name() string {
+	return e.name
+}
+
+func (e *sequenceEffect) Run(ctx context.Context, render func(context.Context, rgbColor) error) error {
+	current := colorOff
+	return repeatCycles(ctx, e.repeat, render, func() error {
+		for _, frame := range e.keyframes {
+			if frame.fade {
+				from := current
+				err := animate(ctx, frame.duration, func(progress float64) error {
+					return render(ctx, from.blend(frame.color, progress))
+				})
+				if err != nil {
+					return err
+				}
+				if err := render(ctx, frame.color); err != nil {
+					return err
+				}
+			} else {
+				if err := render(ctx, frame.color); err != nil {
+					return err
+				}
+				if err := sleepContext(ctx, frame.duration); err != nil {
+					return err
+				}
+			}
+			current = frame.color
+		}
+		return nil
+	})
+}
+
+// sleepContext waits for the duration or until the context is cancelled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
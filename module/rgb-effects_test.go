@@ -0,0 +1,174 @@
+package learningrobotics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewEffect(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]any
+		want    rgbEffect
+		wantErr string
+	}{
+		{
+			name:   "party",
+			values: map[string]any{"name": "party", "repeat": 3.0},
+			want:   &partyEffect{repeat: 3},
+		},
+		{
+			name:   "party occurences",
+			values: map[string]any{"name": "party", "occurences": "5"},
+			want:   &partyEffect{repeat: 5},
+		},
+		{
+			name:   "breathe defaults",
+			values: map[string]any{"name": "breathe", "color": "#ff8800"},
+			want:   &breatheEffect{color: rgbColor{Red: 255, Green: 136}, period: 2 * time.Second},
+		},
+		{
+			name:   "blink",
+			values: map[string]any{"name": "blink", "color": "#00f", "on_ms": 100.0, "off_ms": "300", "repeat": 2.0},
+			want:   &blinkEffect{name: "blink", color: colorBlue, on: 100 * time.Millisecond, off: 300 * time.Millisecond, repeat: 2},
+		},
+		{
+			name:   "strobe defaults",
+			values: map[string]any{"name": "strobe"},
+			want:   &blinkEffect{name: "strobe", color: colorWhite, on: 10 * time.Millisecond, off: 90 * time.Millisecond},
+		},
+		{
+			name:   "rainbow",
+			values: map[string]any{"name": "rainbow", "period_ms": 1000.0},
+			want:   &rainbowEffect{period: time.Second},
+		},
+		{
+			name:    "missing name",
+			values:  map[string]any{},
+			wantErr: "start_effect.name is required",
+		},
+		{
+			name:    "unknown effect",
+			values:  map[string]any{"name": "disco"},
+			wantErr: `unknown effect "disco": expected one of party, breathe, blink, strobe, rainbow or sequence`,
+		},
+		{
+			name:    "breathe period too short",
+			values:  map[string]any{"name": "breathe", "color": "#ff8800", "period_ms": 10.0},
+			wantErr: "breathe.period_ms must be at least 100, got 10",
+		},
+		{
+			name:    "breathe period too long",
+			values:  map[string]any{"name": "breathe", "color": "#ff8800", "period_ms": 1e15},
+			wantErr: "breathe.period_ms must be at most 3600000, got 1000000000000000",
+		},
+		{
+			name:    "breathe needs a color",
+			values:  map[string]any{"name": "breathe"},
+			wantErr: "breathe.color is required",
+		},
+		{
+			name:    "blink bad color",
+			values:  map[string]any{"name": "blink", "color": map[string]any{"red": 300.0}},
+			wantErr: "blink.color.red must be between 0 and 255, got 300",
+		},
+		{
+			name:    "blink on too short",
+			values:  map[string]any{"name": "blink", "color": "#fff", "on_ms": 5.0},
+			wantErr: "blink.on_ms must be at least 10, got 5",
+		},
+		{
+			name:    "strobe flash as long as period",
+			values:  map[string]any{"name": "strobe", "period_ms": 50.0, "flash_ms": 50.0},
+			wantErr: "strobe.flash_ms must be shorter than period_ms",
+		},
+		{
+			name:    "negative repeat",
+			values:  map[string]any{"name": "rainbow", "repeat": -1.0},
+			wantErr: "rainbow.repeat must be at least 0, got -1",
+		},
+		{
+			name:    "sequence needs keyframes",
+			values:  map[string]any{"name": "sequence", "keyframes": []any{}},
+			wantErr: "sequence.keyframes must be a non-empty list",
+		},
+		{
+			name:    "sequence keyframe needs a duration",
+			values:  map[string]any{"name": "sequence", "keyframes": []any{map[string]any{"color": "#f00"}}},
+			wantErr: "sequence.keyframes[0].duration_ms is required",
+		},
+		{
+			name: "sequence keyframe bad fade",
+			values: map[string]any{"name": "sequence", "keyframes": []any{
+				map[string]any{"color": "#f00", "duration_ms": 100.0},
+				map[string]any{"color": "#00f", "duration_ms": 100.0, "fade": "sometimes"},
+			}},
+			wantErr: "sequence.keyframes[1].fade must be a boolean, got sometimes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEffect(newCommandArgs("start_effect", tt.values))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("newEffect() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newEffect() failed: %v", err)
+			}
+			if got.Name() != tt.want.Name() {
+				t.Errorf("effect = %s, want %s", got.Name(), tt.want.Name())
+			}
+			switch want := tt.want.(type) {
+			case *partyEffect:
+				if *got.(*partyEffect) != *want {
+					t.Errorf("effect = %+v, want %+v", got, want)
+				}
+			case *breatheEffect:
+				if *got.(*breatheEffect) != *want {
+					t.Errorf("effect = %+v, want %+v", got, want)
+				}
+			case *blinkEffect:
+				if *got.(*blinkEffect) != *want {
+					t.Errorf("effect = %+v, want %+v", got, want)
+				}
+			case *rainbowEffect:
+				if *got.(*rainbowEffect) != *want {
+					t.Errorf("effect = %+v, want %+v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceKeyframes(t *testing.T) {
+	effect, err := newEffect(newCommandArgs("start_effect", map[string]any{
+		"name": "sequence",
+		"keyframes": []any{
+			map[string]any{"color": "#ff0000", "duration_ms": 500.0},
+			map[string]any{"color": map[string]any{"blue": 255.0}, "duration_ms": "1000", "fade": true},
+		},
+		"repeat": 2.0,
+	}))
+	if err != nil {
+		t.Fatalf("newEffect() failed: %v", err)
+	}
+	sequence := effect.(*sequenceEffect)
+	want := []keyframe{
+		{color: colorRed, duration: 500 * time.Millisecond},
+		{color: colorBlue, duration: time.Second, fade: true},
+	}
+	if len(sequence.keyframes) != len(want) {
+		t.Fatalf("keyframes = %+v, want %+v", sequence.keyframes, want)
+	}
+	for i := range want {
+		if sequence.keyframes[i] != want[i] {
+			t.Errorf("keyframes[%d] = %+v, want %+v", i, sequence.keyframes[i], want[i])
+		}
+	}
+	if sequence.repeat != 2 {
+		t.Errorf("repeat = %d, want 2", sequence.repeat)
+	}
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"math"
+	"sync"
 	"time"
 
 	"go.viam.com/rdk/components/board"
@@ -30,8 +31,40 @@ type RGBLedConfig struct {
 	GreenPin  string `json:"green_pin"`
 	BluePin   string `json:"blue_pin"`
 	BoardName string `json:"board_name"`
+
+	// PWMFrequencyHz is the PWM frequency used when mixing colors with set_color.
+	PWMFrequencyHz uint `json:"pwm_frequency_hz,omitempty"`
+
+	// ActiveLow drives every channel low to turn it on, as with common-anode LEDs.
+	// The per-channel invert settings override it for a single channel.
+	ActiveLow   bool  `json:"active_low,omitempty"`
+	InvertRed   *bool `json:"invert_red,omitempty"`
+	InvertGreen *bool `json:"invert_green,omitempty"`
+	InvertBlue  *bool `json:"invert_blue,omitempty"`
+
+	// Calibration applies a gamma curve and per-channel scale factors to PWM colors.
+	Calibration *RGBCalibration `json:"calibration,omitempty"`
+
+	// StateFile is where the last color and brightness are saved so they can be
+	// restored when the service is rebuilt. StartupColor is shown when there is
+	// no saved state.
+	StateFile    string `json:"state_file,omitempty"`
+	StartupColor string `json:"startup_color,omitempty"`
+
+	// OnClose is "off" (default), "hold" or "set", which shows the hex color
+	// in OnCloseValue when the service closes.
+	OnClose      string `json:"on_close,omitempty"`
+	OnCloseValue string `json:"on_close_value,omitempty"`
+}
+
+// rgbLedState is the state saved to the state file.
+type rgbLedState struct {
+	Color      string  `json:"color"`
+	Brightness float64 `json:"brightness"`
 }
 
+const defaultPWMFrequencyHz = 1000
+
 // Validate ensures all parts of the config are valid and important fields exist.
 // Returns implicit required (first return) and optional (second return) dependencies based on the config.
 // The path is the JSON path in your robot's config (not the `Config` struct) to the
@@ -49,6 +82,24 @@ func (cfg *RGBLedConfig) Validate(path string) ([]string, []string, error) {
 	if cfg.BoardName == "" {
 		return nil, nil, errors.New("board_name is required")
 	}
+	if cfg.Calibration != nil {
+		if err := cfg.Calibration.Validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if cfg.StartupColor != "" {
+		if _, err := parseHexColor(cfg.StartupColor); err != nil {
+			return nil, nil, fmt.Errorf("startup_color: %w", err)
+		}
+	}
+	if err := validateOnClose(cfg.OnClose, cfg.OnCloseValue != ""); err != nil {
+		return nil, nil, err
+	}
+	if cfg.OnCloseValue != "" {
+		if _, err := parseHexColor(cfg.OnCloseValue); err != nil {
+			return nil, nil, fmt.Errorf("on_close_value: %w", err)
+		}
+	}
 	return nil, nil, nil
 }
 
@@ -62,10 +113,47 @@ type learningRoboticsRgbLed struct {
 
 	cancelCtx  context.Context
 	cancelFunc func()
+	statePath  string
+
+	redPin   ledChannel
+	greenPin ledChannel
+	bluePin  ledChannel
+
+	// effectMu serialises starting and stopping effects, so a new effect
+	// cannot start between another being stopped and replaced. It is held
+	// while waiting for an effect to exit and must be taken before mu.
+	effectMu     sync.Mutex
+	effectClosed bool
+
+	mu          sync.Mutex
+	effect      *effectRun
+	calibration colorCalibration
+	// color is the color currently shown before brightness is applied
+	color      rgbColor
+	brightness float64
+	changed    time.Time
+	output     ledOutput
+}
+
+// ledOutput is what was last written to the pins, used to detect drift.
+type ledOutput struct {
+	pwm   bool
+	duty  [3]float64
+	valid bool
+}
 
-	redPin   board.GPIOPin
-	greenPin board.GPIOPin
-	bluePin  board.GPIOPin
+// stateDriftTolerance is how far a read back duty cycle may differ from the
+// written one before it is reported as drift.
+const stateDriftTolerance = 0.02
+
+// effectRun tracks an effect running on the background goroutine.
+type effectRun struct {
+	name     string
+	started  time.Time
+	finished time.Time
+	err      error
+	cancel   context.CancelFunc
+	done     chan struct{}
 }
 
 func newLearningRoboticsRgbLed(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (resource.Resource, error) {
@@ -86,31 +174,57 @@ func NewRgbLed(ctx context.Context, deps resource.Dependencies, name resource.Na
 		cancelFunc()
 		return nil, err
 	}
-	redPin, err := board.GPIOPinByName(conf.RedPin)
+	redPin, err := newLedChannel(board, conf.RedPin, conf.ActiveLow, conf.InvertRed)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	greenPin, err := board.GPIOPinByName(conf.GreenPin)
+	greenPin, err := newLedChannel(board, conf.GreenPin, conf.ActiveLow, conf.InvertGreen)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	bluePin, err := board.GPIOPinByName(conf.BluePin)
+	bluePin, err := newLedChannel(board, conf.BluePin, conf.ActiveLow, conf.InvertBlue)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
 
 	s := &learningRoboticsRgbLed{
-		name:       name,
-		logger:     logger,
-		cfg:        conf,
-		cancelCtx:  cancelCtx,
-		cancelFunc: cancelFunc,
-		redPin:     redPin,
-		greenPin:   greenPin,
-		bluePin:    bluePin,
+		name:        name,
+		logger:      logger,
+		cfg:         conf,
+		cancelCtx:   cancelCtx,
+		cancelFunc:  cancelFunc,
+		redPin:      redPin,
+		greenPin:    greenPin,
+		bluePin:     bluePin,
+		statePath:   resolveStatePath(conf.StateFile),
+		calibration: conf.Calibration.resolve(identityCalibration),
+		brightness:  1,
+	}
+
+	// the frequency is fixed until the next rebuild, and setting it again on
+	// every frame restarts the PWM cycle on some boards
+	freq := conf.PWMFrequencyHz
+	if freq == 0 {
+		freq = defaultPWMFrequencyHz
+	}
+	for _, channel := range []ledChannel{redPin, greenPin, bluePin} {
+		if err := channel.pin.SetPWMFreq(ctx, freq, map[string]interface{}{}); err != nil {
+			// primaries are still shown digitally without PWM
+			logger.Warnf("could not set PWM frequency, mixed colors may not display: %v", err)
+			break
+		}
+	}
+
+	// start from a known state, since the pins' power-on levels depend on the
+	// LED's polarity
+	color, brightness := s.startupState()
+	s.brightness = brightness
+	if err := s.show(s.cancelCtx, color); err != nil {
+		cancelFunc()
+		return nil, err
 	}
 	return s, nil
 }
@@ -120,25 +234,135 @@ func (s *learningRoboticsRgbLed) Name() resource.Name {
 }
 
 func (s *learningRoboticsRgbLed) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
-	if partyData, ok := cmd["party_mode"]; ok {
+	if _, ok := cmd["effect_status"]; ok {
+		return s.effectStatus(), nil
+	}
+
+	if _, ok := cmd["get_state"]; ok {
+		return s.getState(ctx), nil
+	}
+
+	if _, ok := cmd["stop_effect"]; ok {
+		s.stopEffect()
+		return map[string]any{"status": "success"}, nil
+	}
 
-		occurences, ok := partyData.(map[string]any)["occurences"]
-		if !ok {
-			return nil, fmt.Errorf("occurences is required")
+	args := newCommandArgs("", cmd)
+
+	if args.has("start_effect") {
+		params, err := args.mapArg("start_effect")
+		if err != nil {
+			return nil, err
 		}
-		occurStr, ok := occurences.(string)
-		if !ok {
-			return nil, fmt.Errorf("occurences must be a string")
+		effect, err := newEffect(params)
+		if err != nil {
+			return nil, err
 		}
-		occurInt, err := strconv.Atoi(occurStr)
+		s.startEffect(effect)
+		return map[string]any{"status": "started", "effect": effect.Name()}, nil
+	}
+
+	if args.has("set_calibration") {
+		values, err := args.mapArg("set_calibration")
 		if err != nil {
-			return nil, fmt.Errorf("occurences must be an integer string: %v", err)
+			return nil, err
 		}
-		err = s.partyMode(occurInt)
+		calibration, err := parseCalibration(values)
 		if err != nil {
 			return nil, err
 		}
-		return map[string]any{"status": "success"}, nil
+		s.mu.Lock()
+		s.calibration = calibration.resolve(s.calibration)
+		active := s.calibration
+		s.mu.Unlock()
+		return map[string]any{"status": "success", "calibration": active.toMap()}, nil
+	}
+
+	if args.has("calibration_sweep") {
+		params, err := args.mapArg("calibration_sweep")
+		if err != nil {
+			return nil, err
+		}
+		step, err := effectParams{args: params}.duration("step_ms", 1500, 100)
+		if err != nil {
+			return nil, err
+		}
+		s.startEffect(&sequenceEffect{name: "calibration_sweep", keyframes: calibrationSweep(step), repeat: 1})
+		return map[string]any{"status": "started", "effect": "calibration_sweep"}, nil
+	}
+
+	if args.has("set_brightness") {
+		brightness, err := args.requiredFloatArg("set_brightness")
+		if err != nil {
+			return nil, err
+		}
+		if err := args.checkRange("set_brightness", brightness, 0, 1); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.brightness = brightness
+		s.changed = time.Now()
+		color := s.color
+		running := s.effect != nil && s.effect.finished.IsZero()
+		s.mu.Unlock()
+		// a running effect picks up the new brightness on its next frame
+		if !running && color != colorOff {
+			if err := s.render(s.cancelCtx); err != nil {
+				return nil, err
+			}
+		}
+		s.saveState()
+		return map[string]any{"status": "success", "brightness": brightness, "color": color.Hex()}, nil
+	}
+
+	if args.has("party_mode") {
+		partyArgs, err := args.mapArg("party_mode")
+		if err != nil {
+			return nil, err
+		}
+		// occurences was originally sent as a string such as "5", which is still accepted
+		occurInt, err := partyArgs.requiredIntArg("occurences")
+		if err != nil {
+			return nil, err
+		}
+		if err := partyArgs.checkMin("occurences", occurInt, 1); err != nil {
+			return nil, err
+		}
+		s.startEffect(&partyEffect{repeat: occurInt})
+		return map[string]any{"status": "started", "effect": "party"}, nil
+	}
+
+	// any direct color command pre-empts a running effect
+	s.stopEffect()
+
+	if colorData, ok := cmd["set_color"]; ok {
+		color, err := parseColor("set_color", colorData)
+		if err != nil {
+			return nil, err
+		}
+		err = s.setColor(s.cancelCtx, color)
+		if err != nil {
+			return nil, err
+		}
+		s.saveState()
+		return map[string]any{"status": "success", "color": color.Hex()}, nil
+	}
+
+	if args.has("set_hsv") {
+		hsvArgs, err := args.mapArg("set_hsv")
+		if err != nil {
+			return nil, err
+		}
+		color, err := parseHSV(hsvArgs)
+		if err != nil {
+			return nil, err
+		}
+		err = s.setColor(s.cancelCtx, color)
+		if err != nil {
+			return nil, err
+		}
+		s.saveState()
+		return map[string]any{"status": "success", "color": color.Hex()}, nil
 	}
 
 	if _, ok := cmd["turn_off"]; ok {
@@ -146,6 +370,7 @@ func (s *learningRoboticsRgbLed) DoCommand(ctx context.Context, cmd map[string]i
 		if err != nil {
 			return nil, err
 		}
+		s.saveState()
 		return map[string]any{"status": "success"}, nil
 	}
 
@@ -154,6 +379,7 @@ func (s *learningRoboticsRgbLed) DoCommand(ctx context.Context, cmd map[string]i
 		if err != nil {
 			return nil, err
 		}
+		s.saveState()
 		return map[string]any{"status": "success"}, nil
 	}
 
@@ -162,6 +388,7 @@ func (s *learningRoboticsRgbLed) DoCommand(ctx context.Context, cmd map[string]i
 		if err != nil {
 			return nil, err
 		}
+		s.saveState()
 		return map[string]any{"status": "success"}, nil
 	}
 
@@ -170,104 +397,317 @@ func (s *learningRoboticsRgbLed) DoCommand(ctx context.Context, cmd map[string]i
 		if err != nil {
 			return nil, err
 		}
+		s.saveState()
 		return map[string]any{"status": "success"}, nil
 	}
 
 	return nil, fmt.Errorf("Unknown command: %v", cmd)
 }
 
-func (s *learningRoboticsRgbLed) Close(context.Context) error {
-	// Put close code here
+func (s *learningRoboticsRgbLed) Close(ctx context.Context) error {
+	s.effectMu.Lock()
+	s.effectClosed = true
+	s.stopEffectLocked()
+	s.effectMu.Unlock()
 	s.cancelFunc()
-	return nil
+	return applyOnClose(ctx, s.logger, s.cfg.OnClose, func(ctx context.Context, policy string) error {
+		if policy == onCloseSet {
+			// already checked by Validate
+			color, _ := parseHexColor(s.cfg.OnCloseValue)
+			return s.show(ctx, color)
+		}
+		return s.setChannels(ctx, false, false, false)
+	})
 }
 
 func (s *learningRoboticsRgbLed) makeRed() error {
-	err := s.bluePin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
-	}
-	err = s.greenPin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
+	return s.setPrimary(s.cancelCtx, colorRed)
+}
+
+func (s *learningRoboticsRgbLed) makeGreen() error {
+	return s.setPrimary(s.cancelCtx, colorGreen)
+}
+
+func (s *learningRoboticsRgbLed) makeBlue() error {
+	return s.setPrimary(s.cancelCtx, colorBlue)
+}
+
+func (s *learningRoboticsRgbLed) turnOff() error {
+	return s.setPrimary(s.cancelCtx, colorOff)
+}
+
+// show displays color digitally when it is a primary color or off, and with
+// PWM otherwise.
+func (s *learningRoboticsRgbLed) show(ctx context.Context, color rgbColor) error {
+	switch color {
+	case colorOff, colorRed, colorGreen, colorBlue:
+		return s.setPrimary(ctx, color)
+	default:
+		return s.setColor(ctx, color)
 	}
-	err = s.redPin.Set(s.cancelCtx, true, map[string]interface{}{})
-	if err != nil {
-		return err
+}
+
+// setPrimary shows a primary color or off by switching channels fully on or
+// off, falling back to PWM while the LED is dimmed or calibrated.
+func (s *learningRoboticsRgbLed) setPrimary(ctx context.Context, color rgbColor) error {
+	s.mu.Lock()
+	s.color = color
+	s.changed = time.Now()
+	brightness, calibration := s.brightness, s.calibration
+	s.mu.Unlock()
+	if (brightness < 1 || calibration != identityCalibration) && color != colorOff {
+		return s.render(ctx)
 	}
-	return nil
+	return s.setChannels(ctx, color.Red > 0, color.Green > 0, color.Blue > 0)
 }
 
-func (s *learningRoboticsRgbLed) makeGreen() error {
-	err := s.bluePin.Set(s.cancelCtx, false, map[string]interface{}{})
+// setChannels turns each channel fully on or off.
+func (s *learningRoboticsRgbLed) setChannels(ctx context.Context, red, green, blue bool) error {
+	err := s.bluePin.set(ctx, blue)
 	if err != nil {
 		return err
 	}
-	err = s.greenPin.Set(s.cancelCtx, true, map[string]interface{}{})
+	err = s.greenPin.set(ctx, green)
 	if err != nil {
 		return err
 	}
-	err = s.redPin.Set(s.cancelCtx, false, map[string]interface{}{})
+	err = s.redPin.set(ctx, red)
 	if err != nil {
 		return err
 	}
+
+	level := func(on bool) float64 {
+		if on {
+			return 1
+		}
+		return 0
+	}
+	s.mu.Lock()
+	s.output = ledOutput{duty: [3]float64{level(red), level(green), level(blue)}, valid: true}
+	s.mu.Unlock()
 	return nil
 }
 
-func (s *learningRoboticsRgbLed) makeBlue() error {
+// setColor makes color the current color and renders it.
+func (s *learningRoboticsRgbLed) setColor(ctx context.Context, color rgbColor) error {
+	s.mu.Lock()
+	s.color = color
+	s.changed = time.Now()
+	s.mu.Unlock()
+	return s.render(ctx)
+}
 
-	err := s.bluePin.Set(s.cancelCtx, true, map[string]interface{}{})
-	if err != nil {
-		return err
+// render mixes the current color at the current brightness by driving each
+// channel with a PWM duty cycle.
+func (s *learningRoboticsRgbLed) render(ctx context.Context) error {
+	s.mu.Lock()
+	color, brightness, calibration := s.color, s.brightness, s.calibration
+	s.mu.Unlock()
+
+	red, green, blue := calibration.dutyCycles(color, brightness)
+	channels := []struct {
+		channel ledChannel
+		duty    float64
+	}{
+		{s.redPin, red},
+		{s.greenPin, green},
+		{s.bluePin, blue},
 	}
-	err = s.greenPin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
-	}
-	err = s.redPin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
+	for _, c := range channels {
+		if err := c.channel.setDuty(ctx, c.duty); err != nil {
+			return err
+		}
 	}
+
+	s.mu.Lock()
+	s.output = ledOutput{pwm: true, duty: [3]float64{red, green, blue}, valid: true}
+	s.mu.Unlock()
 	return nil
 }
 
-func (s *learningRoboticsRgbLed) turnOff() error {
-	err := s.bluePin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
+// getState reports the current color, brightness and effect, and reads PWM
+// pins back where the board supports it to detect drift from what was written.
+// Digital pins report only the level written to them.
+func (s *learningRoboticsRgbLed) getState(ctx context.Context) map[string]any {
+	s.mu.Lock()
+	color, brightness, changed, output := s.color, s.brightness, s.changed, s.output
+	effect := ""
+	if s.effect != nil && s.effect.finished.IsZero() {
+		effect = s.effect.name
 	}
-	err = s.greenPin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
+	s.mu.Unlock()
+
+	state := map[string]any{
+		"color":      color.Hex(),
+		"red":        int(color.Red),
+		"green":      int(color.Green),
+		"blue":       int(color.Blue),
+		"brightness": brightness,
+		"effect":     effect,
 	}
-	err = s.redPin.Set(s.cancelCtx, false, map[string]interface{}{})
-	if err != nil {
-		return err
+	if !changed.IsZero() {
+		state["changed_at"] = changed.Format(time.RFC3339Nano)
+	}
+	if !output.valid {
+		return state
 	}
-	return nil
-}
 
-func (s *learningRoboticsRgbLed) partyMode(occurences int) error {
-	for range occurences {
-		err := s.makeRed()
-		if err != nil {
-			return err
+	mode := "digital"
+	if output.pwm {
+		mode = "pwm"
+	}
+	state["output"] = mode
+
+	drift := false
+	pins := map[string]any{}
+	for i, channel := range []struct {
+		name    string
+		channel ledChannel
+	}{
+		{"red", s.redPin},
+		{"green", s.greenPin},
+		{"blue", s.bluePin},
+	} {
+		pin := map[string]any{"expected": output.duty[i]}
+		if !output.pwm {
+			pins[channel.name] = pin
+			continue
 		}
-		time.Sleep(time.Millisecond * 100)
-		err = s.makeGreen()
+		actual, err := channel.channel.readDuty(ctx)
 		if err != nil {
-			return err
+			pin["error"] = err.Error()
+		} else {
+			pin["actual"] = actual
+			if math.Abs(actual-output.duty[i]) > stateDriftTolerance {
+				drift = true
+			}
 		}
-		time.Sleep(time.Millisecond * 100)
-		err = s.makeBlue()
-		if err != nil {
-			return err
+		pins[channel.name] = pin
+	}
+	state["pins"] = pins
+	if output.pwm {
+		state["drift"] = drift
+	}
+	return state
+}
+
+// startEffect stops any running effect and runs the new one in the background.
+// Once the LED is closing no new effect starts.
+func (s *learningRoboticsRgbLed) startEffect(effect rgbEffect) {
+	s.effectMu.Lock()
+	defer s.effectMu.Unlock()
+	s.stopEffectLocked()
+	if s.effectClosed {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.cancelCtx)
+	run := &effectRun{
+		name:    effect.Name(),
+		started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.effect = run
+	s.mu.Unlock()
+
+	go func() {
+		defer close(run.done)
+		err := effect.Run(ctx, s.show)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warnf("effect %s failed: %v", run.name, err)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if ctx.Err() == nil {
+			run.err = err
 		}
-		time.Sleep(time.Millisecond * 100)
+		run.finished = time.Now()
+	}()
+}
+
+// stopEffect cancels the running effect, if any, and waits for it to exit so
+// that it can no longer write to the pins.
+func (s *learningRoboticsRgbLed) stopEffect() {
+	s.effectMu.Lock()
+	defer s.effectMu.Unlock()
+	s.stopEffectLocked()
+}
+
+// stopEffectLocked is stopEffect for callers holding effectMu.
+func (s *learningRoboticsRgbLed) stopEffectLocked() {
+	s.mu.Lock()
+	run := s.effect
+	s.mu.Unlock()
+	if run == nil {
+		return
+	}
+	run.cancel()
+	<-run.done
+}
+
+func (s *learningRoboticsRgbLed) effectStatus() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.effect == nil {
+		return map[string]any{"running": false}
+	}
+	status := map[string]any{
+		"running":    s.effect.finished.IsZero(),
+		"effect":     s.effect.name,
+		"started_at": s.effect.started.Format(time.RFC3339Nano),
+	}
+	if !s.effect.finished.IsZero() {
+		status["finished_at"] = s.effect.finished.Format(time.RFC3339Nano)
+	}
+	if s.effect.err != nil {
+		status["error"] = s.effect.err.Error()
+	}
+	return status
+}
+
+// startupState returns the color and brightness to show when the service
+// starts: the saved state when there is one, otherwise startup_color or off.
+func (s *learningRoboticsRgbLed) startupState() (rgbColor, float64) {
+	color := colorOff
+	if s.cfg.StartupColor != "" {
+		// already checked by Validate
+		color, _ = parseHexColor(s.cfg.StartupColor)
 	}
-	err := s.turnOff()
+	if s.statePath == "" {
+		return color, 1
+	}
+
+	var state rgbLedState
+	ok, err := loadStateFile(s.statePath, &state)
 	if err != nil {
-		return err
+		s.logger.Warnf("could not restore LED state from %s: %v", s.statePath, err)
+		return color, 1
+	}
+	if !ok {
+		return color, 1
+	}
+	saved, err := parseHexColor(state.Color)
+	if err != nil || state.Brightness < 0 || state.Brightness > 1 {
+		s.logger.Warnf("ignoring invalid LED state in %s: %+v", s.statePath, state)
+		return color, 1
+	}
+	return saved, state.Brightness
+}
+
+// saveState writes the current color and brightness to the state file, if
+// one is configured. Failures are logged rather than failing the command.
+func (s *learningRoboticsRgbLed) saveState() {
+	if s.statePath == "" {
+		return
+	}
+	s.mu.Lock()
+	state := rgbLedState{Color: s.color.Hex(), Brightness: s.brightness}
+	s.mu.Unlock()
+	if err := saveStateFile(s.statePath, state); err != nil {
+		s.logger.Warnf("could not save LED state to %s: %v", s.statePath, err)
 	}
-	return nil
 }
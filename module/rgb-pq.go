@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"go.viam.com/rdk/components/board"
 	sw "go.viam.com/rdk/components/switch"
@@ -28,6 +29,32 @@ type RGBPQConfig struct {
 	GreenPin  string `json:"green_pin"`
 	BluePin   string `json:"blue_pin"`
 	BoardName string `json:"board_name"`
+
+	// ActiveLow drives every channel low to turn it on, as with common-anode LEDs.
+	// The per-channel invert settings override it for a single channel.
+	ActiveLow   bool  `json:"active_low,omitempty"`
+	InvertRed   *bool `json:"invert_red,omitempty"`
+	InvertGreen *bool `json:"invert_green,omitempty"`
+	InvertBlue  *bool `json:"invert_blue,omitempty"`
+
+	// StateFile is where the last position is saved so it can be restored when
+	// the switch is rebuilt. StartupColor is the position label shown when
+	// there is no saved state.
+	StateFile    string `json:"state_file,omitempty"`
+	StartupColor string `json:"startup_color,omitempty"`
+
+	// OnClose is "off" (default), "hold" or "set", which moves to the position
+	// labelled OnCloseValue when the switch closes.
+	OnClose      string `json:"on_close,omitempty"`
+	OnCloseValue string `json:"on_close_value,omitempty"`
+}
+
+// rgbPqPositions are the labels of the switch positions, in order.
+var rgbPqPositions = []string{"off", "red", "green", "blue"}
+
+// rgbPqState is the state saved to the state file.
+type rgbPqState struct {
+	Position uint32 `json:"position"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -48,6 +75,15 @@ func (cfg *RGBPQConfig) Validate(path string) ([]string, []string, error) {
 	if cfg.BoardName == "" {
 		return nil, nil, errors.New("board_name is required")
 	}
+	if cfg.StartupColor != "" && !slices.Contains(rgbPqPositions, cfg.StartupColor) {
+		return nil, nil, fmt.Errorf("startup_color must be one of %v", rgbPqPositions)
+	}
+	if err := validateOnClose(cfg.OnClose, cfg.OnCloseValue != ""); err != nil {
+		return nil, nil, err
+	}
+	if cfg.OnCloseValue != "" && !slices.Contains(rgbPqPositions, cfg.OnCloseValue) {
+		return nil, nil, fmt.Errorf("on_close_value must be one of %v", rgbPqPositions)
+	}
 	return []string{cfg.BoardName}, nil, nil
 }
 
@@ -61,10 +97,11 @@ type learningRoboticsRgbPq struct {
 
 	cancelCtx  context.Context
 	cancelFunc func()
+	statePath  string
 
-	redPin   board.GPIOPin
-	greenPin board.GPIOPin
-	bluePin  board.GPIOPin
+	redPin   ledChannel
+	greenPin ledChannel
+	bluePin  ledChannel
 	position uint32
 }
 
@@ -87,17 +124,17 @@ func NewRgbPq(ctx context.Context, deps resource.Dependencies, name resource.Nam
 		cancelFunc()
 		return nil, err
 	}
-	redPin, err := board.GPIOPinByName(conf.RedPin)
+	redPin, err := newLedChannel(board, conf.RedPin, conf.ActiveLow, conf.InvertRed)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	greenPin, err := board.GPIOPinByName(conf.GreenPin)
+	greenPin, err := newLedChannel(board, conf.GreenPin, conf.ActiveLow, conf.InvertGreen)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	bluePin, err := board.GPIOPinByName(conf.BluePin)
+	bluePin, err := newLedChannel(board, conf.BluePin, conf.ActiveLow, conf.InvertBlue)
 	if err != nil {
 		cancelFunc()
 		return nil, err
@@ -112,8 +149,16 @@ func NewRgbPq(ctx context.Context, deps resource.Dependencies, name resource.Nam
 		redPin:     redPin,
 		greenPin:   greenPin,
 		bluePin:    bluePin,
+		statePath:  resolveStatePath(conf.StateFile),
 		position:   0,
 	}
+
+	// start from a known state, since the pins' power-on levels depend on the
+	// LED's polarity
+	if err := s.setPosition(ctx, s.startupPosition()); err != nil {
+		cancelFunc()
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -124,25 +169,44 @@ func (s *learningRoboticsRgbPq) Name() resource.Name {
 // SetPosition sets the switch to the specified position.
 // Position must be within the valid range for the switch type.
 func (s *learningRoboticsRgbPq) SetPosition(ctx context.Context, position uint32, extra map[string]interface{}) error {
+	// callers such as the event-system repeat the same position many times a
+	// second, so the state file is only rewritten when it changes
+	changed := position != s.position
+	if err := s.setPosition(ctx, position); err != nil {
+		return err
+	}
+	if changed {
+		s.saveState()
+	}
+	return nil
+}
+
+// setPosition drives the pins for position without saving it. Every channel
+// is turned off even when one fails, and the failures are returned together.
+func (s *learningRoboticsRgbPq) setPosition(ctx context.Context, position uint32) error {
 	s.position = position
 
-	s.bluePin.Set(ctx, false, extra)
-	s.greenPin.Set(ctx, false, extra)
-	s.redPin.Set(ctx, false, extra)
+	if err := errors.Join(
+		s.bluePin.set(ctx, false),
+		s.greenPin.set(ctx, false),
+		s.redPin.set(ctx, false),
+	); err != nil {
+		return err
+	}
 
 	switch s.position {
 	case 1:
-		err := s.redPin.Set(ctx, true, extra)
+		err := s.redPin.set(ctx, true)
 		if err != nil {
 			return err
 		}
 	case 2:
-		err := s.greenPin.Set(ctx, true, extra)
+		err := s.greenPin.set(ctx, true)
 		if err != nil {
 			return err
 		}
 	case 3:
-		err := s.bluePin.Set(ctx, true, extra)
+		err := s.bluePin.set(ctx, true)
 		if err != nil {
 			return err
 		}
@@ -158,15 +222,58 @@ func (s *learningRoboticsRgbPq) GetPosition(ctx context.Context, extra map[strin
 // GetNumberOfPositions returns the total number of valid positions for this switch, along with their labels.
 // Labels should either be nil, empty, or the same length has the number of positions.
 func (s *learningRoboticsRgbPq) GetNumberOfPositions(ctx context.Context, extra map[string]interface{}) (uint32, []string, error) {
-	return 4, []string{"off", "red", "green", "blue"}, nil
+	return uint32(len(rgbPqPositions)), rgbPqPositions, nil
 }
 
 func (s *learningRoboticsRgbPq) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (s *learningRoboticsRgbPq) Close(context.Context) error {
-	// Put close code here
+func (s *learningRoboticsRgbPq) Close(ctx context.Context) error {
 	s.cancelFunc()
-	return nil
+	return applyOnClose(ctx, s.logger, s.cfg.OnClose, func(ctx context.Context, policy string) error {
+		position := uint32(0)
+		if policy == onCloseSet {
+			position = uint32(slices.Index(rgbPqPositions, s.cfg.OnCloseValue))
+		}
+		return s.setPosition(ctx, position)
+	})
+}
+
+// startupPosition returns the saved position when there is one, otherwise
+// the startup_color position or off.
+func (s *learningRoboticsRgbPq) startupPosition() uint32 {
+	position := uint32(0)
+	if s.cfg.StartupColor != "" {
+		position = uint32(slices.Index(rgbPqPositions, s.cfg.StartupColor))
+	}
+	if s.statePath == "" {
+		return position
+	}
+
+	var state rgbPqState
+	ok, err := loadStateFile(s.statePath, &state)
+	if err != nil {
+		s.logger.Warnf("could not restore switch state from %s: %v", s.statePath, err)
+		return position
+	}
+	if !ok {
+		return position
+	}
+	if state.Position >= uint32(len(rgbPqPositions)) {
+		s.logger.Warnf("ignoring invalid switch position %d in %s", state.Position, s.statePath)
+		return position
+	}
+	return state.Position
+}
+
+// saveState writes the current position to the state file, if one is
+// configured. Failures are logged rather than failing SetPosition.
+func (s *learningRoboticsRgbPq) saveState() {
+	if s.statePath == "" {
+		return
+	}
+	if err := saveStateFile(s.statePath, rgbPqState{Position: s.position}); err != nil {
+		s.logger.Warnf("could not save switch state to %s: %v", s.statePath, err)
+	}
 }
@@ -0,0 +1,145 @@
+package learningrobotics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	sensor "go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+// defaultSpeedOfSound is the speed of sound in dry air at about 20 °C, in m/s.
+const defaultSpeedOfSound = 343.0
+
+// where the speed of sound used for a distance came from
+const (
+	speedSourceDefault     = "default"
+	speedSourceTemperature = "temperature_c"
+	speedSourceSensor      = "sensor"
+)
+
+// climateMaxAge is how long a temperature read from a sensor is reused.
+// Air temperature changes slowly, and reading the sensor on every
+// measurement would delay the trigger.
+const climateMaxAge = 10 * time.Second
+
+// speedOfSound returns the speed of sound in m/s in air at tempC degrees
+// Celsius and humidity percent relative humidity.
+func speedOfSound(tempC, humidity float64) float64 {
+	return 331.3*math.Sqrt(1+tempC/273.15) + 0.0124*humidity
+}
+
+// soundSpeed works out the speed of sound for an ultrasonic sensor from a
+// temperature sensor, a fixed temperature, or the default, in that order.
+type soundSpeed struct {
+	logger logging.Logger
+
+	climate        sensor.Sensor
+	temperatureKey string
+	humidityKey    string
+	temperatureC   *float64
+
+	// the last reading from climate, or the error reading it, reused until
+	// climateMaxAge passes
+	readAt      time.Time
+	readErr     error
+	tempC       float64
+	humidity    float64
+	hasHumidity bool
+}
+
+// newSoundSpeed returns the speed of sound source configured by the
+// temperature attributes of conf.
+func newSoundSpeed(deps resource.Dependencies, conf *UltrasonicSensorConfig, logger logging.Logger) (*soundSpeed, error) {
+	s := &soundSpeed{
+		logger:         logger,
+		temperatureKey: conf.TemperatureKey,
+		humidityKey:    conf.HumidityKey,
+		temperatureC:   conf.TemperatureC,
+	}
+	if s.temperatureKey == "" {
+		s.temperatureKey = "temperature"
+	}
+	if s.humidityKey == "" {
+		s.humidityKey = "humidity"
+	}
+	if conf.TemperatureSensor != "" {
+		climate, err := sensor.FromProvider(deps, conf.TemperatureSensor)
+		if err != nil {
+			return nil, err
+		}
+		s.climate = climate
+	}
+	return s, nil
+}
+
+// speed returns the speed of sound to use along with the readings it is based
+// on. A failing temperature sensor falls back to the fixed temperature or the
+// default rather than failing the measurement.
+func (s *soundSpeed) speed(ctx context.Context) (float64, map[string]interface{}) {
+	if s.climate != nil {
+		if err := s.refresh(ctx); err == nil {
+			// humidity is 0 when the sensor does not report it
+			speed := speedOfSound(s.tempC, s.humidity)
+			info := map[string]interface{}{
+				"speed_of_sound":        speed,
+				"speed_of_sound_source": speedSourceSensor,
+				"temperature_c":         s.tempC,
+			}
+			if s.hasHumidity {
+				info["humidity"] = s.humidity
+			}
+			return speed, info
+		}
+	}
+	if s.temperatureC != nil {
+		speed := speedOfSound(*s.temperatureC, 0)
+		return speed, map[string]interface{}{
+			"speed_of_sound":        speed,
+			"speed_of_sound_source": speedSourceTemperature,
+			"temperature_c":         *s.temperatureC,
+		}
+	}
+	return defaultSpeedOfSound, map[string]interface{}{
+		"speed_of_sound":        defaultSpeedOfSound,
+		"speed_of_sound_source": speedSourceDefault,
+	}
+}
+
+// refresh reads the temperature sensor when the last reading is too old. A
+// failure is also kept for climateMaxAge, so a broken sensor is not retried
+// and logged on every measurement.
+func (s *soundSpeed) refresh(ctx context.Context) error {
+	if !s.readAt.IsZero() && time.Since(s.readAt) < climateMaxAge {
+		return s.readErr
+	}
+	s.readAt = time.Now()
+	s.readErr = s.read(ctx)
+	if s.readErr != nil {
+		s.logger.Warnf("using fallback speed of sound, failed to read temperature: %v", s.readErr)
+	}
+	return s.readErr
+}
+
+func (s *soundSpeed) read(ctx context.Context) error {
+	readings, err := s.climate.Readings(ctx, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	tempC, err := decodeFloat(s.temperatureKey, readings[s.temperatureKey])
+	if err != nil {
+		return fmt.Errorf("temperature sensor reading: %w", err)
+	}
+	humidity, hasHumidity := 0.0, false
+	if raw, ok := readings[s.humidityKey]; ok {
+		if humidity, err = decodeFloat(s.humidityKey, raw); err != nil {
+			return fmt.Errorf("temperature sensor reading: %w", err)
+		}
+		hasHumidity = true
+	}
+	s.tempC, s.humidity, s.hasHumidity = tempC, humidity, hasHumidity
+	return nil
+}
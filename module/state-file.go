@@ -0,0 +1,61 @@
+package learningrobotics
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// resolveStatePath makes relative state file paths relative to the module's
+// data directory when viam-server provides one.
+func resolveStatePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	if dataDir := os.Getenv("VIAM_MODULE_DATA"); dataDir != "" {
+		return filepath.Join(dataDir, path)
+	}
+	return path
+}
+
+// loadStateFile decodes the JSON state saved at path into state. It returns
+// false without an error when nothing has been saved yet.
+func loadStateFile(path string, state any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// saveStateFile writes state as JSON to path. The file is replaced atomically
+// so a crash mid-write never leaves a truncated state behind.
+func saveStateFile(path string, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,275 @@
+package learningrobotics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sensor "go.viam.com/rdk/components/sensor"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+var (
+	UltrasonicArray = resource.NewModel("mattmacf", "learning-robotics", "ultrasonic-array")
+)
+
+func init() {
+	resource.RegisterComponent(sensor.API, UltrasonicArray,
+		resource.Registration[sensor.Sensor, *UltrasonicArrayConfig]{
+			Constructor: newUltrasonicArray,
+		},
+	)
+}
+
+// defaultInterSensorDelayMs is how long the array waits after one sensor's
+// measurement before triggering the next, so echoes of the earlier pulse have
+// died out.
+const defaultInterSensorDelayMs = 20
+
+// readings keys an ultrasonic-array uses besides the sensor names
+var arrayReadingKeys = []string{"unit", "sensors"}
+
+// UltrasonicArraySensor is one sensor of an ultrasonic-array, wired like an
+// ultrasonic-sensor with either a trigger and echo pin or a single signal pin.
+type UltrasonicArraySensor struct {
+	Name          string `json:"name"`
+	TriggerPin    string `json:"trigger_pin,omitempty"`
+	EchoInterrupt string `json:"echo_interrupt_pin,omitempty"`
+	SignalPin     string `json:"signal_pin,omitempty"`
+}
+
+type UltrasonicArrayConfig struct {
+	BoardName string                  `json:"board_name"`
+	Sensors   []UltrasonicArraySensor `json:"sensors"`
+
+	// InterSensorDelayMs is the quiet time between one sensor's measurement
+	// ending and the next sensor being triggered.
+	InterSensorDelayMs *int `json:"inter_sensor_delay_ms,omitempty"`
+
+	// shared by every sensor, as in UltrasonicSensorConfig
+	TemperatureC      *float64 `json:"temperature_c,omitempty"`
+	TemperatureSensor string   `json:"temperature_sensor,omitempty"`
+	TemperatureKey    string   `json:"temperature_key,omitempty"`
+	HumidityKey       string   `json:"humidity_key,omitempty"`
+	EchoTimeoutMs     *int     `json:"echo_timeout_ms,omitempty"`
+	Units             string   `json:"units,omitempty"`
+	MinRange          *float64 `json:"min_range,omitempty"`
+	MaxRange          *float64 `json:"max_range,omitempty"`
+}
+
+// Validate ensures all parts of the config are valid and important fields exist.
+// Returns implicit required (first return) and optional (second return) dependencies based on the config.
+// The path is the JSON path in your robot's config (not the `Config` struct) to the
+// resource being validated; e.g. "components.0".
+func (cfg *UltrasonicArrayConfig) Validate(path string) ([]string, []string, error) {
+	if cfg.BoardName == "" {
+		return nil, nil, errors.New("board_name is required")
+	}
+	if len(cfg.Sensors) == 0 {
+		return nil, nil, errors.New("sensors must list at least one sensor")
+	}
+	if cfg.InterSensorDelayMs != nil && *cfg.InterSensorDelayMs < 0 {
+		return nil, nil, fmt.Errorf("inter_sensor_delay_ms must not be negative, got %d", *cfg.InterSensorDelayMs)
+	}
+
+	names := map[string]bool{}
+	// a pin shared by two sensors would trigger both or hear the other's
+	// echoes, so every trigger, echo and signal pin belongs to one sensor
+	type sensorPin struct{ key, pin string }
+	pins := map[string]string{}
+	var deps []string
+	for i, s := range cfg.Sensors {
+		path := fmt.Sprintf("sensors.%d", i)
+		if s.Name == "" {
+			return nil, nil, fmt.Errorf("%s.name is required", path)
+		}
+		for _, key := range arrayReadingKeys {
+			if s.Name == key {
+				return nil, nil, fmt.Errorf("%s.name %q is reserved for the array's readings", path, s.Name)
+			}
+		}
+		if names[s.Name] {
+			return nil, nil, fmt.Errorf("%s.name %q is used by more than one sensor", path, s.Name)
+		}
+		names[s.Name] = true
+
+		var sensorPins []sensorPin
+		if s.SignalPin != "" {
+			if s.TriggerPin != "" || s.EchoInterrupt != "" {
+				return nil, nil, fmt.Errorf("%s.signal_pin replaces trigger_pin and echo_interrupt_pin, set either signal_pin or both of them", path)
+			}
+			sensorPins = append(sensorPins, sensorPin{"signal_pin", s.SignalPin})
+		} else {
+			if s.TriggerPin == "" {
+				return nil, nil, fmt.Errorf("%s.trigger_pin is required", path)
+			}
+			if s.EchoInterrupt == "" {
+				return nil, nil, fmt.Errorf("%s.echo_interrupt_pin is required", path)
+			}
+			sensorPins = append(sensorPins,
+				sensorPin{"trigger_pin", s.TriggerPin},
+				sensorPin{"echo_interrupt_pin", s.EchoInterrupt},
+			)
+		}
+		for _, p := range sensorPins {
+			if other, ok := pins[p.pin]; ok {
+				if other == s.Name {
+					return nil, nil, fmt.Errorf("%s.%s %q is also its trigger pin, use signal_pin for a 3-wire sensor", path, p.key, p.pin)
+				}
+				return nil, nil, fmt.Errorf("%s.%s %q is already used by %q", path, p.key, p.pin, other)
+			}
+			pins[p.pin] = s.Name
+		}
+
+		// each sensor is checked with the shared attributes as an
+		// ultrasonic-sensor would check it
+		var err error
+		deps, _, err = cfg.sensorConfig(s).Validate(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return deps, nil, nil
+}
+
+// sensorConfig returns the ultrasonic-sensor config of one sensor of the
+// array.
+func (cfg *UltrasonicArrayConfig) sensorConfig(s UltrasonicArraySensor) *UltrasonicSensorConfig {
+	return &UltrasonicSensorConfig{
+		TriggerPin:        s.TriggerPin,
+		EchoInterrupt:     s.EchoInterrupt,
+		SignalPin:         s.SignalPin,
+		BoardName:         cfg.BoardName,
+		TemperatureC:      cfg.TemperatureC,
+		TemperatureSensor: cfg.TemperatureSensor,
+		TemperatureKey:    cfg.TemperatureKey,
+		HumidityKey:       cfg.HumidityKey,
+		EchoTimeoutMs:     cfg.EchoTimeoutMs,
+		Units:             cfg.Units,
+		MinRange:          cfg.MinRange,
+		MaxRange:          cfg.MaxRange,
+	}
+}
+
+// ultrasonicArray measures several ultrasonic sensors one at a time, so no
+// sensor hears another's pulse.
+type ultrasonicArray struct {
+	resource.AlwaysRebuild
+
+	name resource.Name
+
+	logger logging.Logger
+	cfg    *UltrasonicArrayConfig
+
+	names   []string
+	sensors []*ultraSensorUltrasonicSensor
+	delay   time.Duration
+
+	// mu serialises rounds of measurements and guards the soundSpeed the
+	// sensors share
+	mu sync.Mutex
+	// lastDone is when the last measurement of any sensor ended
+	lastDone time.Time
+}
+
+func newUltrasonicArray(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (sensor.Sensor, error) {
+	conf, err := resource.NativeConfig[*UltrasonicArrayConfig](rawConf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUltrasonicArray(ctx, deps, rawConf.ResourceName(), conf, logger)
+}
+
+func NewUltrasonicArray(ctx context.Context, deps resource.Dependencies, name resource.Name, conf *UltrasonicArrayConfig, logger logging.Logger) (sensor.Sensor, error) {
+	// the temperature is the same for every sensor, so it is read once
+	speed, err := newSoundSpeed(deps, conf.sensorConfig(conf.Sensors[0]), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := time.Duration(defaultInterSensorDelayMs) * time.Millisecond
+	if conf.InterSensorDelayMs != nil {
+		delay = time.Duration(*conf.InterSensorDelayMs) * time.Millisecond
+	}
+
+	a := &ultrasonicArray{
+		name:   name,
+		logger: logger,
+		cfg:    conf,
+		delay:  delay,
+	}
+	for _, sensorConf := range conf.Sensors {
+		s, err := newUltrasonicSensor(ctx, deps, name, conf.sensorConfig(sensorConf), speed, logger.Sublogger(sensorConf.Name))
+		if err != nil {
+			a.Close(ctx)
+			return nil, fmt.Errorf("sensor %q: %w", sensorConf.Name, err)
+		}
+		a.names = append(a.names, sensorConf.Name)
+		a.sensors = append(a.sensors, s)
+	}
+	return a, nil
+}
+
+func (a *ultrasonicArray) Name() resource.Name {
+	return a.name
+}
+
+// Readings triggers every sensor in turn, waiting the inter-sensor delay
+// before each one, and returns their distances keyed by name. The full
+// reading of each sensor is under "sensors". A sensor that fails is reported
+// there with its error; Readings only fails when every sensor does.
+func (a *ultrasonicArray) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	readings := map[string]interface{}{
+		"unit": a.sensors[0].limits.unit,
+	}
+	sensorReadings := map[string]interface{}{}
+	var errs []error
+	for i, s := range a.sensors {
+		// the quiet time also separates the first sensor from the last
+		// sensor of the previous round
+		if err := sleepContext(ctx, a.delay-time.Since(a.lastDone)); err != nil {
+			return nil, err
+		}
+		reading, err := s.measure(ctx)
+		a.lastDone = time.Now()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sensor %q: %w", a.names[i], err))
+			sensorReadings[a.names[i]] = map[string]interface{}{
+				"valid": false,
+				"error": err.Error(),
+			}
+			continue
+		}
+		if distance, ok := reading["distance"]; ok {
+			readings[a.names[i]] = distance
+		}
+		sensorReadings[a.names[i]] = reading
+	}
+	if len(errs) == len(a.sensors) {
+		return nil, errors.Join(errs...)
+	}
+	readings["sensors"] = sensorReadings
+	return readings, nil
+}
+
+func (a *ultrasonicArray) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (a *ultrasonicArray) Close(ctx context.Context) error {
+	var errs []error
+	for _, s := range a.sensors {
+		errs = append(errs, s.Close(ctx))
+	}
+	return errors.Join(errs...)
+}
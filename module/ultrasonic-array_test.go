@@ -0,0 +1,97 @@
+package learningrobotics
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUltrasonicArrayValidate(t *testing.T) {
+	minRange, noMinRange := 0.05, 0.0
+	tests := []struct {
+		name     string
+		sensors  []UltrasonicArraySensor
+		minRange *float64
+		wantErr  string
+	}{
+		{
+			name: "separate pins",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", TriggerPin: "11", EchoInterrupt: "13"},
+				{Name: "back", TriggerPin: "15", EchoInterrupt: "16"},
+			},
+		},
+		{
+			name: "signal pins",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", SignalPin: "16"},
+				{Name: "back", SignalPin: "18"},
+			},
+			minRange: &minRange,
+		},
+		{
+			name: "shared echo pin",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", TriggerPin: "11", EchoInterrupt: "13"},
+				{Name: "back", TriggerPin: "15", EchoInterrupt: "13"},
+			},
+			wantErr: `sensors.1.echo_interrupt_pin "13" is already used by "front"`,
+		},
+		{
+			name: "shared trigger pin",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", TriggerPin: "11", EchoInterrupt: "13"},
+				{Name: "back", TriggerPin: "11", EchoInterrupt: "16"},
+			},
+			wantErr: `sensors.1.trigger_pin "11" is already used by "front"`,
+		},
+		{
+			name: "trigger pin is another sensor's echo pin",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", TriggerPin: "11", EchoInterrupt: "13"},
+				{Name: "back", TriggerPin: "13", EchoInterrupt: "16"},
+			},
+			wantErr: `sensors.1.trigger_pin "13" is already used by "front"`,
+		},
+		{
+			name: "echo pin is another sensor's signal pin",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", SignalPin: "16"},
+				{Name: "back", TriggerPin: "11", EchoInterrupt: "16"},
+			},
+			minRange: &minRange,
+			wantErr:  `sensors.1.echo_interrupt_pin "16" is already used by "front"`,
+		},
+		{
+			name:    "echo pin is its own trigger pin",
+			sensors: []UltrasonicArraySensor{{Name: "front", TriggerPin: "11", EchoInterrupt: "11"}},
+			wantErr: `sensors.0.echo_interrupt_pin "11" is also its trigger pin, use signal_pin for a 3-wire sensor`,
+		},
+		{
+			name: "every sensor is checked with the shared attributes",
+			sensors: []UltrasonicArraySensor{
+				{Name: "front", TriggerPin: "11", EchoInterrupt: "13"},
+				{Name: "back", SignalPin: "16"},
+			},
+			minRange: &noMinRange,
+			wantErr:  "sensors.1: min_range must be greater than 0 with signal_pin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &UltrasonicArrayConfig{BoardName: "my-board", Sensors: tt.sensors, MinRange: tt.minRange}
+			deps, _, err := cfg.Validate("components.0")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			if !slices.Equal(deps, []string{"my-board"}) {
+				t.Errorf("deps = %v, want [my-board]", deps)
+			}
+		})
+	}
+}
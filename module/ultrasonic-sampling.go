@@ -0,0 +1,156 @@
+package learningrobotics
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"go.viam.com/rdk/logging"
+)
+
+// filters applied to the distances of a sampling ultrasonic-sensor
+const (
+	filterMedian        = "median"
+	filterMovingAverage = "moving_average"
+	filterNone          = "none"
+)
+
+const defaultWindowSize = 5
+
+var errNoSamples = errors.New("no ultrasonic samples have been taken yet")
+
+// distanceSample is one measurement taken by the sampling loop.
+type distanceSample struct {
+	reading map[string]interface{}
+	err     error
+	at      time.Time
+}
+
+// distanceSampler keeps the most recent samples of a continuously sampling
+// ultrasonic-sensor in a ring buffer and filters their distances.
+type distanceSampler struct {
+	filter string
+
+	mu      sync.Mutex
+	samples []distanceSample
+	// next is the ring index the next sample is written to
+	next  int
+	count int
+}
+
+func newDistanceSampler(filter string, windowSize int) *distanceSampler {
+	if filter == "" {
+		filter = filterMedian
+	}
+	return &distanceSampler{filter: filter, samples: make([]distanceSample, windowSize)}
+}
+
+// run measures at every interval until ctx is cancelled. Failed measurements
+// are kept as samples so Readings can report them.
+func (d *distanceSampler) run(
+	ctx context.Context,
+	interval time.Duration,
+	measure func(context.Context) (map[string]interface{}, error),
+	logger logging.Logger,
+) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reading, err := measure(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Debugf("ultrasonic sample failed: %v", err)
+		}
+		d.add(distanceSample{reading: reading, err: err, at: time.Now()})
+	}
+}
+
+func (d *distanceSampler) add(sample distanceSample) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples[d.next] = sample
+	d.next = (d.next + 1) % len(d.samples)
+	d.count = min(d.count+1, len(d.samples))
+}
+
+// distances returns the distances of the valid samples in the window, oldest
+// first. The caller holds mu.
+func (d *distanceSampler) distances() []float64 {
+	var distances []float64
+	for i := range d.count {
+		sample := d.samples[(d.next-d.count+i+len(d.samples))%len(d.samples)]
+		if distance, ok := sample.reading["distance"].(float64); ok && sample.err == nil {
+			distances = append(distances, distance)
+		}
+	}
+	return distances
+}
+
+// readings returns the latest sample with its distance replaced by the
+// filtered distance of the window. When the latest sample has no distance,
+// because it failed or nothing was in range, the reading has none either, so
+// a stale obstacle is never reported alongside valid: false.
+func (d *distanceSampler) readings() (map[string]interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.count == 0 {
+		return nil, errNoSamples
+	}
+	latest := d.samples[(d.next-1+len(d.samples))%len(d.samples)]
+	distances := d.distances()
+	if latest.err != nil && len(distances) == 0 {
+		return nil, latest.err
+	}
+
+	readings := map[string]interface{}{}
+	if latest.err != nil {
+		readings["valid"] = false
+		readings["error"] = latest.err.Error()
+	} else {
+		readings = maps.Clone(latest.reading)
+		delete(readings, "distance")
+		if raw, ok := latest.reading["distance"]; ok {
+			readings["raw_distance"] = raw
+		}
+	}
+	if _, ok := latest.reading["distance"]; ok && latest.err == nil {
+		readings["distance"] = filterDistances(d.filter, distances)
+	}
+	readings["filter"] = d.filter
+	readings["samples"] = len(distances)
+	readings["sample_age_ms"] = time.Since(latest.at).Milliseconds()
+	return readings, nil
+}
+
+// filterDistances combines the distances of a window, oldest first.
+func filterDistances(filter string, distances []float64) float64 {
+	switch filter {
+	case filterMovingAverage:
+		var sum float64
+		for _, distance := range distances {
+			sum += distance
+		}
+		return sum / float64(len(distances))
+	case filterNone:
+		return distances[len(distances)-1]
+	default:
+		sorted := slices.Sorted(slices.Values(distances))
+		mid := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[mid-1] + sorted[mid]) / 2
+		}
+		return sorted[mid]
+	}
+}
@@ -0,0 +1,163 @@
+package learningrobotics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFilterDistances(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    string
+		distances []float64
+		want      float64
+	}{
+		{name: "median odd", filter: filterMedian, distances: []float64{0.5, 3.9, 0.4}, want: 0.5},
+		{name: "median even", filter: filterMedian, distances: []float64{0.4, 0.2, 0.8, 0.6}, want: 0.5},
+		{name: "median single", filter: filterMedian, distances: []float64{1.2}, want: 1.2},
+		{name: "median is the default", filter: "", distances: []float64{0.1, 2.0, 0.3}, want: 0.3},
+		{name: "moving average", filter: filterMovingAverage, distances: []float64{0.2, 0.4, 0.9}, want: 0.5},
+		{name: "none is newest", filter: filterNone, distances: []float64{0.2, 0.4, 0.9}, want: 0.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterDistances(tt.filter, tt.distances); got != tt.want {
+				t.Errorf("filterDistances(%q, %v) = %v, want %v", tt.filter, tt.distances, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDistancesKeepsInput(t *testing.T) {
+	distances := []float64{0.9, 0.1, 0.5}
+	filterDistances(filterMedian, distances)
+	if distances[0] != 0.9 || distances[1] != 0.1 || distances[2] != 0.5 {
+		t.Errorf("median reordered its input to %v", distances)
+	}
+}
+
+func inRangeSample(distance float64) distanceSample {
+	return distanceSample{
+		reading: map[string]interface{}{"distance": distance, "no_echo": false, "valid": true},
+		at:      time.Now(),
+	}
+}
+
+func noEchoSample() distanceSample {
+	return distanceSample{
+		reading: map[string]interface{}{"no_echo": true, "valid": false},
+		at:      time.Now(),
+	}
+}
+
+func failedSample() distanceSample {
+	return distanceSample{err: errNoEchoPulse, at: time.Now()}
+}
+
+func TestDistanceSamplerReadings(t *testing.T) {
+	tests := []struct {
+		name         string
+		windowSize   int
+		samples      []distanceSample
+		wantDistance float64
+		wantNone     bool
+		wantSamples  int
+		wantValid    bool
+		wantErr      error
+	}{
+		{
+			name:       "no samples",
+			windowSize: 3,
+			wantErr:    errNoSamples,
+		},
+		{
+			name:         "median of window",
+			windowSize:   3,
+			samples:      []distanceSample{inRangeSample(0.5), inRangeSample(3.0), inRangeSample(0.4)},
+			wantDistance: 0.5,
+			wantSamples:  3,
+			wantValid:    true,
+		},
+		{
+			name:       "oldest sample leaves the window",
+			windowSize: 2,
+			samples: []distanceSample{
+				inRangeSample(9), inRangeSample(0.25), inRangeSample(0.75),
+			},
+			wantDistance: 0.5,
+			wantSamples:  2,
+			wantValid:    true,
+		},
+		{
+			name:       "no echo newest sample has no distance",
+			windowSize: 3,
+			samples: []distanceSample{
+				inRangeSample(0.2), inRangeSample(0.2), noEchoSample(),
+			},
+			wantNone:    true,
+			wantSamples: 2,
+		},
+		{
+			name:       "failed newest sample has no distance",
+			windowSize: 3,
+			samples: []distanceSample{
+				inRangeSample(0.2), failedSample(),
+			},
+			wantNone:    true,
+			wantSamples: 1,
+		},
+		{
+			name:       "no echo samples are left out of the filter",
+			windowSize: 3,
+			samples: []distanceSample{
+				noEchoSample(), inRangeSample(0.6), inRangeSample(0.2),
+			},
+			wantDistance: 0.4,
+			wantSamples:  2,
+			wantValid:    true,
+		},
+		{
+			name:       "only failures",
+			windowSize: 2,
+			samples:    []distanceSample{failedSample(), failedSample()},
+			wantErr:    errNoEchoPulse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sampler := newDistanceSampler("", tt.windowSize)
+			for _, sample := range tt.samples {
+				sampler.add(sample)
+			}
+			readings, err := sampler.readings()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("readings() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readings() failed: %v", err)
+			}
+
+			distance, ok := readings["distance"]
+			if tt.wantNone {
+				if ok {
+					t.Errorf("readings() has distance %v, want none", distance)
+				}
+			} else if distance != tt.wantDistance {
+				t.Errorf("distance = %v, want %v", distance, tt.wantDistance)
+			}
+			if valid, _ := readings["valid"].(bool); valid != tt.wantValid {
+				t.Errorf("valid = %v, want %v", valid, tt.wantValid)
+			}
+			if valid, _ := readings["valid"].(bool); !valid && ok {
+				t.Errorf("readings() has both a distance and valid: false: %v", readings)
+			}
+			if readings["samples"] != tt.wantSamples {
+				t.Errorf("samples = %v, want %d", readings["samples"], tt.wantSamples)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
+	"sync"
 	"time"
 
 	"go.viam.com/rdk/components/board"
@@ -25,10 +27,46 @@ func init() {
 	)
 }
 
+// defaultEchoTimeoutMs is the echo pulse width an HC-SR04 reports when
+// nothing is in range.
+const defaultEchoTimeoutMs = 38
+
+var errNoEchoPulse = errors.New("no echo pulse from the ultrasonic sensor, check its wiring and power")
+
 type UltrasonicSensorConfig struct {
-	TriggerPin    string `json:"trigger_pin"`
-	EchoInterrupt string `json:"echo_interrupt_pin"`
+	TriggerPin    string `json:"trigger_pin,omitempty"`
+	EchoInterrupt string `json:"echo_interrupt_pin,omitempty"`
 	BoardName     string `json:"board_name"`
+
+	// SignalPin is the shared trigger and echo pin of a 3-wire sensor such as
+	// the Parallax PING))), used instead of TriggerPin and EchoInterrupt. The
+	// board must be able to drive a digital interrupt pin as an output.
+	SignalPin string `json:"signal_pin,omitempty"`
+
+	// SampleRateHz enables continuous sampling: the sensor triggers itself at
+	// this rate and Readings returns the Filter of the last WindowSize samples.
+	SampleRateHz float64 `json:"sample_rate_hz,omitempty"`
+	Filter       string  `json:"filter,omitempty"`
+	WindowSize   *int    `json:"window_size,omitempty"`
+
+	// TemperatureC is the air temperature used to work out the speed of
+	// sound. TemperatureSensor names a sensor to read the temperature, and
+	// humidity when it reports it, from instead; TemperatureC is then only
+	// used when that sensor fails.
+	TemperatureC      *float64 `json:"temperature_c,omitempty"`
+	TemperatureSensor string   `json:"temperature_sensor,omitempty"`
+	TemperatureKey    string   `json:"temperature_key,omitempty"`
+	HumidityKey       string   `json:"humidity_key,omitempty"`
+
+	// EchoTimeoutMs is how long to wait for each edge of the echo pulse. An
+	// echo pulse that does not end in time means nothing is in range.
+	EchoTimeoutMs *int `json:"echo_timeout_ms,omitempty"`
+
+	// Units is m (default), cm, mm or in. Distances outside MinRange and
+	// MaxRange, given in Units, are flagged instead of reported.
+	Units    string   `json:"units,omitempty"`
+	MinRange *float64 `json:"min_range,omitempty"`
+	MaxRange *float64 `json:"max_range,omitempty"`
 }
 
 // Validate ensures all parts of the config are valid and important fields exist.
@@ -37,16 +75,74 @@ type UltrasonicSensorConfig struct {
 // resource being validated; e.g. "components.0".
 func (cfg *UltrasonicSensorConfig) Validate(path string) ([]string, []string, error) {
 	// Add config validation code here
-	if cfg.TriggerPin == "" {
-		return nil, nil, errors.New("trigger_pin is required")
-	}
-	if cfg.EchoInterrupt == "" {
-		return nil, nil, errors.New("echo_interrupt_pin is required")
+	if cfg.SignalPin != "" {
+		if cfg.TriggerPin != "" || cfg.EchoInterrupt != "" {
+			return nil, nil, errors.New("signal_pin replaces trigger_pin and echo_interrupt_pin, set either signal_pin or both of them")
+		}
+	} else {
+		if cfg.TriggerPin == "" {
+			return nil, nil, errors.New("trigger_pin is required")
+		}
+		if cfg.EchoInterrupt == "" {
+			return nil, nil, errors.New("echo_interrupt_pin is required")
+		}
 	}
 	if cfg.BoardName == "" {
 		return nil, nil, errors.New("board_name is required")
 	}
-	return []string{cfg.BoardName}, nil, nil
+	if cfg.EchoTimeoutMs != nil && *cfg.EchoTimeoutMs <= 0 {
+		return nil, nil, fmt.Errorf("echo_timeout_ms must be positive, got %d", *cfg.EchoTimeoutMs)
+	}
+	if cfg.SampleRateHz < 0 {
+		return nil, nil, fmt.Errorf("sample_rate_hz must not be negative, got %v", cfg.SampleRateHz)
+	}
+	// a measurement can wait a whole echo timeout, so faster rates only queue up
+	if maxRate := cfg.maxSampleRateHz(); cfg.SampleRateHz > maxRate {
+		return nil, nil, fmt.Errorf("sample_rate_hz must be at most %.4g with a %d ms echo timeout, got %v",
+			maxRate, cfg.echoTimeoutMs(), cfg.SampleRateHz)
+	}
+	switch cfg.Filter {
+	case "", filterMedian, filterMovingAverage, filterNone:
+	default:
+		return nil, nil, fmt.Errorf("filter must be one of %s, %s or %s, got %q", filterMedian, filterMovingAverage, filterNone, cfg.Filter)
+	}
+	if cfg.WindowSize != nil && *cfg.WindowSize < 1 {
+		return nil, nil, fmt.Errorf("window_size must be at least 1, got %d", *cfg.WindowSize)
+	}
+	if err := validateUnit(cfg.Units); err != nil {
+		return nil, nil, err
+	}
+	limits := newDistanceRange(cfg.Units, cfg.MinRange, cfg.MaxRange)
+	if limits.min < 0 {
+		return nil, nil, fmt.Errorf("min_range must not be negative, got %v", limits.min)
+	}
+	if limits.min >= limits.max {
+		return nil, nil, fmt.Errorf("min_range (%v) must be less than max_range (%v)", limits.min, limits.max)
+	}
+	if cfg.SignalPin != "" && limits.min == 0 {
+		// pulses shorter than an echo from min_range are taken to be the trigger
+		return nil, nil, errors.New("min_range must be greater than 0 with signal_pin")
+	}
+	if cfg.TemperatureC != nil && (*cfg.TemperatureC < -40 || *cfg.TemperatureC > 85) {
+		return nil, nil, fmt.Errorf("temperature_c must be between -40 and 85, got %v", *cfg.TemperatureC)
+	}
+	deps := []string{cfg.BoardName}
+	if cfg.TemperatureSensor != "" {
+		deps = append(deps, cfg.TemperatureSensor)
+	}
+	return deps, nil, nil
+}
+
+func (cfg *UltrasonicSensorConfig) echoTimeoutMs() int {
+	if cfg.EchoTimeoutMs != nil {
+		return *cfg.EchoTimeoutMs
+	}
+	return defaultEchoTimeoutMs
+}
+
+// maxSampleRateHz is the rate at which samples are one echo timeout apart.
+func (cfg *UltrasonicSensorConfig) maxSampleRateHz() float64 {
+	return 1000 / float64(cfg.echoTimeoutMs())
 }
 
 type ultraSensorUltrasonicSensor struct {
@@ -63,6 +159,28 @@ type ultraSensorUltrasonicSensor struct {
 	triggerPin    board.GPIOPin
 	echoInterrupt board.DigitalInterrupt
 	ticksChan     chan board.Tick
+	echoTimeout   time.Duration
+	limits        distanceRange
+	// singlePin is set when triggerPin and echoInterrupt are the same pin
+	singlePin bool
+
+	// measureMu serialises measurements, since concurrent triggers corrupt
+	// each other's echoes. It guards the tick timestamps below.
+	measureMu sync.Mutex
+	// lastTickNs is the newest tick timestamp seen, and readingStartNs the
+	// newest seen before the current reading was triggered. Ticks are compared
+	// with each other rather than the host clock, which the board's tick
+	// timestamps may not share.
+	lastTickNs     uint64
+	readingStartNs uint64
+
+	// soundSpeed is guarded by measureMu, or by the array's mu when an
+	// ultrasonic-array shares it between its sensors
+	soundSpeed *soundSpeed
+
+	// sampler is set in continuous sampling mode
+	sampler *distanceSampler
+	workers sync.WaitGroup
 }
 
 func newUltraSensorUltrasonicSensor(ctx context.Context, deps resource.Dependencies, rawConf resource.Config, logger logging.Logger) (sensor.Sensor, error) {
@@ -76,24 +194,55 @@ func newUltraSensorUltrasonicSensor(ctx context.Context, deps resource.Dependenc
 }
 
 func NewUltrasonicSensor(ctx context.Context, deps resource.Dependencies, name resource.Name, conf *UltrasonicSensorConfig, logger logging.Logger) (sensor.Sensor, error) {
+	speed, err := newSoundSpeed(deps, conf, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newUltrasonicSensor(ctx, deps, name, conf, speed, logger)
+}
+
+// newUltrasonicSensor builds a sensor that works out distances with speed.
+func newUltrasonicSensor(ctx context.Context, deps resource.Dependencies, name resource.Name, conf *UltrasonicSensorConfig, speed *soundSpeed, logger logging.Logger) (*ultraSensorUltrasonicSensor, error) {
 	cancelCtx, cancelFunc := context.WithCancel(context.Background())
-	ticksChan := make(chan board.Tick, 2)
+	// room for stray edges, so a burst cannot block the tick stream
+	ticksChan := make(chan board.Tick, 16)
 
 	piBoard, err := board.FromProvider(deps, conf.BoardName)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	triggerPin, err := piBoard.GPIOPinByName(conf.TriggerPin)
+	triggerPinName, echoInterruptName := conf.TriggerPin, conf.EchoInterrupt
+	if conf.SignalPin != "" {
+		triggerPinName, echoInterruptName = conf.SignalPin, conf.SignalPin
+	}
+	// the interrupt is looked up first, since some boards give up a pin's
+	// GPIO when it becomes an interrupt
+	echoInterrupt, err := piBoard.DigitalInterruptByName(echoInterruptName)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
-	echoInterrupt, err := piBoard.DigitalInterruptByName(conf.EchoInterrupt)
+	triggerPin, err := piBoard.GPIOPinByName(triggerPinName)
 	if err != nil {
 		cancelFunc()
 		return nil, err
 	}
+	if conf.SignalPin != "" {
+		// boards such as genericlinux ones return the interrupt itself, which
+		// cannot be set, so check the pin can be driven before measuring
+		if err := triggerPin.Set(ctx, false, map[string]interface{}{}); err != nil {
+			cancelFunc()
+			return nil, fmt.Errorf("signal_pin %s cannot be driven as an output while it is a digital interrupt on board %s, "+
+				"which cannot run a 3-wire sensor: %w", conf.SignalPin, conf.BoardName, err)
+		}
+		if _, err := triggerPin.Get(ctx, map[string]interface{}{}); err != nil {
+			cancelFunc()
+			return nil, fmt.Errorf("signal_pin %s cannot be read back as an input: %w", conf.SignalPin, err)
+		}
+	}
+
+	echoTimeout := time.Duration(conf.echoTimeoutMs()) * time.Millisecond
 
 	s := &ultraSensorUltrasonicSensor{
 		name:          name,
@@ -103,10 +252,24 @@ func NewUltrasonicSensor(ctx context.Context, deps resource.Dependencies, name r
 		cancelFunc:    cancelFunc,
 		triggerPin:    triggerPin,
 		echoInterrupt: echoInterrupt,
+		singlePin:     conf.SignalPin != "",
 		ticksChan:     ticksChan,
+		echoTimeout:   echoTimeout,
+		limits:        newDistanceRange(conf.Units, conf.MinRange, conf.MaxRange),
+		soundSpeed:    speed,
 	}
 
 	piBoard.StreamTicks(cancelCtx, []board.DigitalInterrupt{echoInterrupt}, ticksChan, map[string]interface{}{})
+	if conf.SampleRateHz > 0 {
+		windowSize := defaultWindowSize
+		if conf.WindowSize != nil {
+			windowSize = *conf.WindowSize
+		}
+		s.sampler = newDistanceSampler(conf.Filter, windowSize)
+		s.workers.Go(func() {
+			s.sampler.run(cancelCtx, time.Duration(float64(time.Second)/conf.SampleRateHz), s.measure, logger)
+		})
+	}
 	return s, nil
 }
 
@@ -115,6 +278,24 @@ func (s *ultraSensorUltrasonicSensor) Name() resource.Name {
 }
 
 func (s *ultraSensorUltrasonicSensor) Readings(ctx context.Context, extra map[string]interface{}) (map[string]interface{}, error) {
+	if s.sampler != nil {
+		return s.sampler.readings()
+	}
+	return s.measure(ctx)
+}
+
+// measure triggers the sensor and times its echo.
+func (s *ultraSensorUltrasonicSensor) measure(ctx context.Context) (map[string]interface{}, error) {
+	s.measureMu.Lock()
+	defer s.measureMu.Unlock()
+
+	// read the temperature before triggering so it does not delay the echo
+	speed, speedInfo := s.soundSpeed.speed(ctx)
+
+	// drop edges left over from an earlier reading that timed out so they are
+	// not paired with this one
+	s.drainTicks()
+
 	// set trigger pin low
 	if err := s.triggerPin.Set(ctx, false, map[string]interface{}{}); err != nil {
 		return nil, err
@@ -129,36 +310,151 @@ func (s *ultraSensorUltrasonicSensor) Readings(ctx context.Context, extra map[st
 	if err := s.triggerPin.Set(ctx, false, map[string]interface{}{}); err != nil {
 		return nil, err
 	}
+	var minWidthNs uint64
+	if s.singlePin {
+		// reading the pin switches it back to an input so the sensor can
+		// drive the echo on it
+		if _, err := s.triggerPin.Get(ctx, map[string]interface{}{}); err != nil {
+			return nil, err
+		}
+		// the interrupt may also have seen the trigger pulse, which is shorter
+		// than any echo the sensor can measure
+		minWidthNs = uint64(2 * distanceToMeters(s.limits.min, s.limits.unit) / speed * 1e9)
+	}
 
-	// the first signal from the interrupt indicates that the sonic
-	// pulse has been sent and the second indicates that the echo has been received
-	var tick board.Tick
-	ticks := make([]board.Tick, 2)
+	pulse, err := s.waitForEcho(ctx, minWidthNs)
+	if err != nil {
+		return nil, err
+	}
+	if !pulse.complete() {
+		return s.noEcho(pulse), nil
+	}
 
-	for i := range 2 {
-		var signalStr string
-		if i == 0 {
-			signalStr = "sound pulse was emitted"
-		} else {
-			signalStr = "echo was received"
-		}
+	timeEmitted := pulse.rise.TimestampNanosec
+	timeReceived := pulse.fall.TimestampNanosec
+	// we calculate the distance to the nearest object based
+	// on the time interval between the sound and its echo
+	// and the speed of sound
+	secondsElapsed := float64(timeReceived-timeEmitted) / math.Pow10(9)
+	distMeters := secondsElapsed * speed / 2
+	distance := s.limits.fromMeters(distMeters)
+	inRange := s.limits.contains(distance)
+	readings := map[string]interface{}{
+		"no_echo":         false,
+		"valid":           true,
+		"in_range":        inRange,
+		"unit":            s.limits.unit,
+		"discarded_ticks": pulse.discarded,
+	}
+	if inRange {
+		readings["distance"] = distance
+	} else {
+		// physically impossible for the sensor, so not passed on as a distance
+		readings["measured_distance"] = distance
+	}
+	maps.Copy(readings, speedInfo)
+	return readings, nil
+}
+
+// echoPulse is a rising edge of the echo pin paired with the falling edge
+// that ends it. fall is zero when the pulse did not end in time.
+type echoPulse struct {
+	rise, fall board.Tick
+	// discarded counts stale and unpaired ticks skipped to find the pulse
+	discarded int
+}
+
+func (p echoPulse) complete() bool {
+	return p.fall.TimestampNanosec != 0
+}
+
+// waitForEcho pairs the next rising and falling edges of the echo pin after a
+// trigger. Ticks stamped no later than the last tick of an earlier reading
+// are stale and skipped, as are falling edges before the pulse starts. A
+// second rising edge restarts the pulse, and complete pulses narrower than
+// minWidthNs are skipped. It returns errNoEchoPulse when no pulse starts
+// within the echo timeout, and an incomplete pulse when it does not end in
+// time.
+func (s *ultraSensorUltrasonicSensor) waitForEcho(ctx context.Context, minWidthNs uint64) (echoPulse, error) {
+	var pulse echoPulse
+	started := false
+
+	timeout := time.NewTimer(s.echoTimeout)
+	defer timeout.Stop()
+	for {
 		select {
-		case tick = <-s.ticksChan:
-			ticks[i] = tick
+		case tick := <-s.ticksChan:
+			if !s.observeTick(tick) {
+				pulse.discarded++
+				continue
+			}
+			switch {
+			case tick.High:
+				if started {
+					pulse.discarded++
+				}
+				pulse.rise = tick
+				started = true
+				timeout.Reset(s.echoTimeout)
+			case !started:
+				pulse.discarded++
+			case tick.TimestampNanosec-pulse.rise.TimestampNanosec >= uint64(s.echoTimeout):
+				// the sensor held the echo pin high until it gave up listening
+				return pulse, nil
+			case tick.TimestampNanosec-pulse.rise.TimestampNanosec < minWidthNs:
+				pulse.discarded += 2
+				started = false
+				timeout.Reset(s.echoTimeout)
+			default:
+				pulse.fall = tick
+				return pulse, nil
+			}
+		case <-timeout.C:
+			if !started {
+				return pulse, errNoEchoPulse
+			}
+			// the sensor holds the echo pin high until it gives up listening
+			// when nothing reflects the pulse
+			return pulse, nil
 		case <-ctx.Done():
-			fmt.Printf("Context cancelled while waiting for signal that %s\n", signalStr)
-			return nil, ctx.Err()
+			return pulse, ctx.Err()
 		}
 	}
+}
 
-	timeEmitted := ticks[0].TimestampNanosec
-	timeReceived := ticks[1].TimestampNanosec
-	// we calculate the distance to the nearest object based
-	// on the time interval between the sound and its echo
-	// and the speed of sound (343 m/s)
-	secondsElapsed := float64(timeReceived-timeEmitted) / math.Pow10(9)
-	distMeters := secondsElapsed * 343 / 2
-	return map[string]interface{}{"distance": distMeters}, nil
+// observeTick records the timestamp of tick and reports whether it is newer
+// than every tick seen before the current reading started.
+func (s *ultraSensorUltrasonicSensor) observeTick(tick board.Tick) bool {
+	fresh := tick.TimestampNanosec > s.readingStartNs
+	s.lastTickNs = max(s.lastTickNs, tick.TimestampNanosec)
+	return fresh
+}
+
+// noEcho is the reading returned when no echo comes back within the echo
+// timeout. It has no distance.
+func (s *ultraSensorUltrasonicSensor) noEcho(pulse echoPulse) map[string]interface{} {
+	return map[string]interface{}{
+		"no_echo":         true,
+		"valid":           false,
+		"in_range":        false,
+		"unit":            s.limits.unit,
+		"echo_timeout_ms": s.echoTimeout.Milliseconds(),
+		"discarded_ticks": pulse.discarded,
+	}
+}
+
+// drainTicks discards any ticks waiting in ticksChan and marks every tick
+// seen so far as belonging to earlier readings.
+func (s *ultraSensorUltrasonicSensor) drainTicks() {
+	for {
+		select {
+		case tick := <-s.ticksChan:
+			s.observeTick(tick)
+		default:
+			s.readingStartNs = s.lastTickNs
+			return
+		}
+	}
 }
 
 func (s *ultraSensorUltrasonicSensor) DoCommand(ctx context.Context, cmd map[string]interface{}) (map[string]interface{}, error) {
@@ -168,5 +464,6 @@ func (s *ultraSensorUltrasonicSensor) DoCommand(ctx context.Context, cmd map[str
 func (s *ultraSensorUltrasonicSensor) Close(context.Context) error {
 	// Put close code here
 	s.cancelFunc()
+	s.workers.Wait()
 	return nil
 }
@@ -0,0 +1,227 @@
+package learningrobotics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.viam.com/rdk/components/board"
+	"go.viam.com/rdk/logging"
+	"go.viam.com/rdk/resource"
+)
+
+// pulseTicks returns the rising and falling ticks of a pulse, in nanoseconds.
+func pulseTicks(rise, fall uint64) []board.Tick {
+	return []board.Tick{
+		{Name: "echo", High: true, TimestampNanosec: rise},
+		{Name: "echo", High: false, TimestampNanosec: fall},
+	}
+}
+
+func TestWaitForEcho(t *testing.T) {
+	const (
+		us = uint64(time.Microsecond)
+		// the echo width of 2 cm at 343 m/s
+		minWidth = 117 * us
+	)
+	tests := []struct {
+		name          string
+		ticks         []board.Tick
+		startNs       uint64
+		minWidthNs    uint64
+		wantRise      uint64
+		wantFall      uint64
+		wantDiscarded int
+		wantErr       error
+	}{
+		{
+			name:     "echo",
+			ticks:    pulseTicks(1000*us, 3900*us),
+			wantRise: 1000 * us,
+			wantFall: 3900 * us,
+		},
+		{
+			name:          "stale ticks of an earlier reading",
+			ticks:         append(pulseTicks(100*us, 200*us), pulseTicks(1000*us, 3900*us)...),
+			startNs:       500 * us,
+			wantRise:      1000 * us,
+			wantFall:      3900 * us,
+			wantDiscarded: 2,
+		},
+		{
+			name:          "falling edge before the pulse",
+			ticks:         append([]board.Tick{{High: false, TimestampNanosec: 900 * us}}, pulseTicks(1000*us, 3900*us)...),
+			wantRise:      1000 * us,
+			wantFall:      3900 * us,
+			wantDiscarded: 1,
+		},
+		{
+			name:          "single pin trigger pulse is skipped",
+			ticks:         append(pulseTicks(1000*us, 1010*us), pulseTicks(1760*us, 4660*us)...),
+			minWidthNs:    minWidth,
+			wantRise:      1760 * us,
+			wantFall:      4660 * us,
+			wantDiscarded: 2,
+		},
+		{
+			name:       "single pin trigger pulse missed",
+			ticks:      pulseTicks(1760*us, 4660*us),
+			minWidthNs: minWidth,
+			wantRise:   1760 * us,
+			wantFall:   4660 * us,
+		},
+		{
+			name: "single pin trigger falling edge missed",
+			ticks: []board.Tick{
+				{High: true, TimestampNanosec: 1000 * us},
+				{High: true, TimestampNanosec: 1760 * us},
+				{High: false, TimestampNanosec: 4660 * us},
+			},
+			minWidthNs:    minWidth,
+			wantRise:      1760 * us,
+			wantFall:      4660 * us,
+			wantDiscarded: 1,
+		},
+		{
+			name:     "short pulse is measured with two pins",
+			ticks:    pulseTicks(1000*us, 1010*us),
+			wantRise: 1000 * us,
+			wantFall: 1010 * us,
+		},
+		{
+			name:    "no echo",
+			wantErr: errNoEchoPulse,
+		},
+		{
+			name:       "only the trigger pulse",
+			ticks:      pulseTicks(1000*us, 1010*us),
+			minWidthNs: minWidth,
+			wantErr:    errNoEchoPulse,
+		},
+		{
+			name:     "echo held until the sensor gives up",
+			ticks:    pulseTicks(1000*us, 1000*us+uint64(5*time.Millisecond)),
+			wantRise: 1000 * us,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ultraSensorUltrasonicSensor{
+				ticksChan:      make(chan board.Tick, 16),
+				echoTimeout:    5 * time.Millisecond,
+				readingStartNs: tt.startNs,
+			}
+			for _, tick := range tt.ticks {
+				s.ticksChan <- tick
+			}
+
+			pulse, err := s.waitForEcho(context.Background(), tt.minWidthNs)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("waitForEcho() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("waitForEcho() failed: %v", err)
+			}
+			if pulse.rise.TimestampNanosec != tt.wantRise || pulse.fall.TimestampNanosec != tt.wantFall {
+				t.Errorf("pulse = %d to %d, want %d to %d",
+					pulse.rise.TimestampNanosec, pulse.fall.TimestampNanosec, tt.wantRise, tt.wantFall)
+			}
+			if pulse.discarded != tt.wantDiscarded {
+				t.Errorf("discarded = %d, want %d", pulse.discarded, tt.wantDiscarded)
+			}
+		})
+	}
+}
+
+// fakeBoard returns pin for every GPIO name, as a genericlinux board returns
+// the digital interrupt for a pin configured as one. The embedded interfaces
+// are nil, so calling anything else panics.
+type fakeBoard struct {
+	board.Board
+	pin board.GPIOPin
+}
+
+func (b fakeBoard) DigitalInterruptByName(name string) (board.DigitalInterrupt, error) {
+	return fakeInterrupt{}, nil
+}
+
+func (b fakeBoard) GPIOPinByName(name string) (board.GPIOPin, error) {
+	return b.pin, nil
+}
+
+type fakeInterrupt struct {
+	board.DigitalInterrupt
+}
+
+type fakeInterruptPin struct {
+	board.GPIOPin
+}
+
+func (p fakeInterruptPin) Set(ctx context.Context, high bool, extra map[string]interface{}) error {
+	return errors.New("cannot set value of a digital interrupt pin")
+}
+
+func TestSignalPinMustBeDrivable(t *testing.T) {
+	deps := resource.Dependencies{board.Named("my-board"): fakeBoard{pin: fakeInterruptPin{}}}
+	conf := &UltrasonicSensorConfig{BoardName: "my-board", SignalPin: "16"}
+
+	_, err := NewUltrasonicSensor(context.Background(), deps, resource.Name{}, conf, logging.NewTestLogger(t))
+	if err == nil {
+		t.Fatal("NewUltrasonicSensor accepted a signal pin that cannot be driven")
+	}
+	if !strings.Contains(err.Error(), "signal_pin 16 cannot be driven") {
+		t.Errorf("error = %q, want it to name signal_pin", err)
+	}
+}
+
+func TestValidateSampleRate(t *testing.T) {
+	timeoutMs := func(ms int) *int { return &ms }
+	tests := []struct {
+		name          string
+		sampleRateHz  float64
+		echoTimeoutMs *int
+		wantErr       string
+	}{
+		{name: "on demand", sampleRateHz: 0},
+		{name: "below the default limit", sampleRateHz: 15},
+		{name: "at a custom limit", sampleRateHz: 100, echoTimeoutMs: timeoutMs(10)},
+		{name: "negative", sampleRateHz: -1, wantErr: "sample_rate_hz must not be negative, got -1"},
+		{
+			name:         "above the default limit",
+			sampleRateHz: 30,
+			wantErr:      "sample_rate_hz must be at most 26.32 with a 38 ms echo timeout, got 30",
+		},
+		{
+			name:          "above a custom limit",
+			sampleRateHz:  1e9,
+			echoTimeoutMs: timeoutMs(10),
+			wantErr:       "sample_rate_hz must be at most 100 with a 10 ms echo timeout, got 1e+09",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &UltrasonicSensorConfig{
+				TriggerPin:    "11",
+				EchoInterrupt: "13",
+				BoardName:     "my-board",
+				SampleRateHz:  tt.sampleRateHz,
+				EchoTimeoutMs: tt.echoTimeoutMs,
+			}
+			_, _, err := conf.Validate("components.0")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}